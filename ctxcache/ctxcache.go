@@ -124,6 +124,106 @@ func Init(ctx context.Context) (_ context.Context, cancel func()) {
 	return context.WithValue(ctx, &cacheKey, m), m.Clear
 }
 
+// InitWithContext behaves like Init, but additionally clears the Cache
+// automatically once ctx is done, so request-scoped data does not outlive
+// its request even if the cancel function is never called.
+//
+// The automatic clearing is registered with context.AfterFunc: no goroutine
+// is started until ctx is done, at which point Clear runs in its own
+// goroutine. Calling the returned cancel function clears the Cache and
+// deregisters the automatic clearing.
+//
+// If ctx already carries a Cache, that Cache belongs to an enclosing scope
+// that may outlive ctx: InitWithContext then returns ctx unchanged with a
+// no-op cancel function, and neither clears the Cache itself.
+func InitWithContext(ctx context.Context) (_ context.Context, cancel func()) {
+	if _, ok := getCache(ctx); ok {
+		return ctx, func() {}
+	}
+	ctx, clearFn := Init(ctx)
+	stop := context.AfterFunc(ctx, clearFn)
+	return ctx, func() {
+		stop()
+		clearFn()
+	}
+}
+
+// Clone attaches a new Cache to the given context that starts with a copy of
+// the values currently held by the context's Cache, and returns the new
+// context along with its own cancel function.
+//
+// The cloned Cache is independent of its parent: values set in the child are
+// not visible to the parent, and clearing either cache does not affect the
+// other. If the context has no Cache, or its Cache is already cleared, the
+// clone starts empty.
+func Clone(ctx context.Context) (_ context.Context, cancel func()) {
+	m := &Cache{values: make(map[any]any)}
+	if cache, ok := getCache(ctx); ok {
+		cache.mutex.Lock()
+		for k, v := range cache.values {
+			m.values[k] = v
+		}
+		cache.mutex.Unlock()
+	}
+	return context.WithValue(ctx, &cacheKey, m), m.Clear
+}
+
+// Merge copies all values set in src's Cache into dst's Cache, supporting
+// fan-out/fan-in patterns where workers compute into private caches, e.g.
+// created with Clone, whose results are then merged back into the parent.
+//
+// The merge is applied atomically: if any key of src is already set in dst,
+// Merge returns ErrKeyAlreadySet for that key and no value is copied. Merging
+// a Cache into itself has no effect.
+//
+// Returns an error if:
+//   - either cache is not initialized, or
+//   - either cache has already been cleared.
+func Merge(dst, src context.Context) error {
+	dstCache, ok := getCache(dst)
+	if !ok {
+		return ErrCacheNotInitialized
+	}
+	srcCache, ok := getCache(src)
+	if !ok {
+		return ErrCacheNotInitialized
+	}
+
+	// Snapshot src first so the two locks are never held together.
+	srcCache.mutex.Lock()
+	if srcCache.cleared {
+		srcCache.mutex.Unlock()
+		return ErrCacheAlreadyCleared
+	}
+	values := make(map[any]any, len(srcCache.values))
+	for k, v := range srcCache.values {
+		values[k] = v
+	}
+	srcCache.mutex.Unlock()
+
+	dstCache.mutex.Lock()
+	defer dstCache.mutex.Unlock()
+
+	if dstCache.cleared {
+		return ErrCacheAlreadyCleared
+	}
+
+	if dstCache == srcCache {
+		return nil
+	}
+
+	for k := range values {
+		if _, ok = dstCache.values[k]; ok {
+			return fmt.Errorf("%s: %w", k, ErrKeyAlreadySet)
+		}
+	}
+
+	for k, v := range values {
+		dstCache.values[k] = v
+	}
+	return nil
+}
+
 // TypedKey represents a strongly typed cache key.
 //
 // A TypedKey is defined by a string identifier and a Go type parameter.
@@ -197,3 +297,54 @@ func Set[T any](ctx context.Context, key string, value T) error {
 	cache.values[k] = value
 	return nil
 }
+
+// Entry pairs a strongly typed key with the value to assign to it in SetAll.
+type Entry struct {
+	key   fmt.Stringer
+	value any
+}
+
+// NewEntry creates an Entry for the key identified by the given string and
+// the type T, as used by Get[T] and Set[T].
+func NewEntry[T any](key string, value T) Entry {
+	return Entry{key: TypedKey[T]{Key: key}, value: value}
+}
+
+// SetAll assigns the values of all entries under a single lock acquisition.
+//
+// The batch is applied atomically: if any key is already set, or appears more
+// than once in the batch, SetAll returns ErrKeyAlreadySet for that key and no
+// value of the batch is assigned.
+//
+// Returns an error if:
+//   - the cache is not initialized, or
+//   - the cache has already been cleared.
+func SetAll(ctx context.Context, entries ...Entry) error {
+	cache, ok := getCache(ctx)
+	if !ok {
+		return ErrCacheNotInitialized
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.cleared {
+		return ErrCacheAlreadyCleared
+	}
+
+	seen := make(map[any]struct{}, len(entries))
+	for _, e := range entries {
+		if _, ok = cache.values[e.key]; ok {
+			return fmt.Errorf("%s: %w", e.key, ErrKeyAlreadySet)
+		}
+		if _, ok = seen[e.key]; ok {
+			return fmt.Errorf("%s: %w", e.key, ErrKeyAlreadySet)
+		}
+		seen[e.key] = struct{}{}
+	}
+
+	for _, e := range entries {
+		cache.values[e.key] = e.value
+	}
+	return nil
+}
@@ -17,8 +17,10 @@
 package ctxcache
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestCtxCache(t *testing.T) {
@@ -102,3 +104,255 @@ func TestCtxCache(t *testing.T) {
 		t.Error("Expected ErrCacheAlreadyCleared after cancel")
 	}
 }
+
+func TestClone(t *testing.T) {
+
+	parent, cancelParent := Init(t.Context())
+	defer cancelParent()
+
+	if err := Set(parent, "key", "value"); err != nil {
+		t.Fatalf("Set string failed: %v", err)
+	}
+
+	child, cancelChild := Clone(parent)
+	if child == parent {
+		t.Error("Expected Clone to return a new context")
+	}
+
+	value, err := Get[string](child, "key")
+	if err != nil {
+		t.Fatalf("Get cloned string failed: %v", err)
+	}
+	if value != "value" {
+		t.Errorf("Expected 'value', got '%s'", value)
+	}
+
+	err = Set(child, "key", "anotherValue")
+	if err == nil || !errors.Is(err, ErrKeyAlreadySet) {
+		t.Error("Expected ErrKeyAlreadySet for a key copied from the parent")
+	}
+
+	if err = Set(child, "child", 42); err != nil {
+		t.Fatalf("Set int failed: %v", err)
+	}
+
+	_, err = Get[int](parent, "child")
+	if err == nil || !errors.Is(err, ErrKeyNotSet) {
+		t.Error("Expected child mutation to be invisible to the parent")
+	}
+
+	cancelChild()
+
+	_, err = Get[string](child, "key")
+	if err == nil || !errors.Is(err, ErrCacheAlreadyCleared) {
+		t.Error("Expected ErrCacheAlreadyCleared after child cancel")
+	}
+
+	value, err = Get[string](parent, "key")
+	if err != nil || value != "value" {
+		t.Errorf("Expected parent to be unaffected by child cancel, got '%s', %v", value, err)
+	}
+
+	empty, cancelEmpty := Clone(t.Context())
+	defer cancelEmpty()
+
+	_, err = Get[string](empty, "key")
+	if err == nil || !errors.Is(err, ErrKeyNotSet) {
+		t.Error("Expected ErrKeyNotSet in a clone of an uninitialized context")
+	}
+}
+
+func TestSetAll(t *testing.T) {
+
+	err := SetAll(t.Context(), NewEntry("key", "value"))
+	if err == nil || !errors.Is(err, ErrCacheNotInitialized) {
+		t.Error("Expected ErrCacheNotInitialized when calling SetAll on unbound context")
+	}
+
+	ctx, cancel := Init(t.Context())
+
+	err = SetAll(ctx,
+		NewEntry("user", "alice"),
+		NewEntry("user", 7),
+		NewEntry("roles", []string{"admin"}),
+	)
+	if err != nil {
+		t.Fatalf("SetAll failed: %v", err)
+	}
+
+	user, err := Get[string](ctx, "user")
+	if err != nil || user != "alice" {
+		t.Errorf("Expected 'alice', got '%s', %v", user, err)
+	}
+	id, err := Get[int](ctx, "user")
+	if err != nil || id != 7 {
+		t.Errorf("Expected 7, got %d, %v", id, err)
+	}
+	roles, err := Get[[]string](ctx, "roles")
+	if err != nil || len(roles) != 1 || roles[0] != "admin" {
+		t.Errorf("Expected [admin], got %v, %v", roles, err)
+	}
+
+	err = SetAll(ctx, NewEntry("tenant", "t1"), NewEntry("user", "bob"))
+	if err == nil || !errors.Is(err, ErrKeyAlreadySet) {
+		t.Error("Expected ErrKeyAlreadySet for a batch conflicting on one key")
+	}
+	if err != nil && err.Error() != "user(string): key already set" {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	_, err = Get[string](ctx, "tenant")
+	if err == nil || !errors.Is(err, ErrKeyNotSet) {
+		t.Error("Expected a conflicting batch to assign no value")
+	}
+
+	err = SetAll(ctx, NewEntry("tenant", "t1"), NewEntry("tenant", "t2"))
+	if err == nil || !errors.Is(err, ErrKeyAlreadySet) {
+		t.Error("Expected ErrKeyAlreadySet for a key repeated within the batch")
+	}
+
+	cancel()
+
+	err = SetAll(ctx, NewEntry("tenant", "t1"))
+	if err == nil || !errors.Is(err, ErrCacheAlreadyCleared) {
+		t.Error("Expected ErrCacheAlreadyCleared when calling SetAll after cancel")
+	}
+}
+
+func TestInitWithContext(t *testing.T) {
+
+	parent, cancelParent := context.WithCancel(t.Context())
+	ctx, cancel := InitWithContext(parent)
+	defer cancel()
+
+	if err := Set(ctx, "key", "value"); err != nil {
+		t.Fatalf("Set string failed: %v", err)
+	}
+
+	cancelParent()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := Get[string](ctx, "key")
+		if errors.Is(err, ErrCacheAlreadyCleared) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected cache to be cleared after parent cancel, got %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx2, cancel2 := InitWithContext(t.Context())
+	if err := Set(ctx2, "key", "value"); err != nil {
+		t.Fatalf("Set string failed: %v", err)
+	}
+
+	cancel2()
+
+	_, err := Get[string](ctx2, "key")
+	if err == nil || !errors.Is(err, ErrCacheAlreadyCleared) {
+		t.Error("Expected ErrCacheAlreadyCleared after explicit cancel")
+	}
+}
+
+func TestInitWithContextNested(t *testing.T) {
+
+	outer, cancel := Init(t.Context())
+	defer cancel()
+
+	if err := Set(outer, "user", "alice"); err != nil {
+		t.Fatalf("Set string failed: %v", err)
+	}
+
+	sub, cancelSub := context.WithTimeout(outer, 10*time.Millisecond)
+	defer cancelSub()
+
+	inner, cancelInner := InitWithContext(sub)
+	if inner != sub {
+		t.Error("Expected InitWithContext to return the context unchanged")
+	}
+
+	<-sub.Done()
+	cancelInner()
+	time.Sleep(10 * time.Millisecond)
+
+	user, err := Get[string](outer, "user")
+	if err != nil || user != "alice" {
+		t.Errorf("Expected the outer cache to survive the sub-operation, got '%s', %v", user, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+
+	parent, cancel := Init(t.Context())
+	defer cancel()
+
+	err := Merge(parent, t.Context())
+	if err == nil || !errors.Is(err, ErrCacheNotInitialized) {
+		t.Error("Expected ErrCacheNotInitialized when merging an unbound context")
+	}
+
+	if err = Set(parent, "user", "alice"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	worker1, cancel1 := Init(t.Context())
+	defer cancel1()
+	worker2, cancel2 := Init(t.Context())
+	defer cancel2()
+
+	_ = Set(worker1, "count", 1)
+	_ = Set(worker2, "total", 2)
+	_ = Set(worker2, "user", 3)
+
+	if err = Merge(parent, worker1); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if err = Merge(parent, worker2); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	user, err := Get[string](parent, "user")
+	if err != nil || user != "alice" {
+		t.Errorf("Expected 'alice', got '%s', %v", user, err)
+	}
+	for key, expect := range map[string]int{"count": 1, "total": 2, "user": 3} {
+		v, err := Get[int](parent, key)
+		if err != nil || v != expect {
+			t.Errorf("Expected %d for %s, got %d, %v", expect, key, v, err)
+		}
+	}
+
+	if err = Merge(parent, parent); err != nil {
+		t.Errorf("Expected merging a cache into itself to succeed, got %v", err)
+	}
+
+	worker3, cancel3 := Init(t.Context())
+	defer cancel3()
+	_ = Set(worker3, "extra", "x")
+	_ = Set(worker3, "count", 10)
+
+	err = Merge(parent, worker3)
+	if err == nil || !errors.Is(err, ErrKeyAlreadySet) {
+		t.Error("Expected ErrKeyAlreadySet when merging a colliding key")
+	}
+	if err != nil && err.Error() != "count(int): key already set" {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	count, _ := Get[int](parent, "count")
+	if count != 1 {
+		t.Errorf("Expected a colliding merge to keep 1, got %d", count)
+	}
+	_, err = Get[string](parent, "extra")
+	if err == nil || !errors.Is(err, ErrKeyNotSet) {
+		t.Error("Expected a colliding merge to copy no value")
+	}
+
+	cancel3()
+	err = Merge(parent, worker3)
+	if err == nil || !errors.Is(err, ErrCacheAlreadyCleared) {
+		t.Error("Expected ErrCacheAlreadyCleared when merging a cleared cache")
+	}
+}
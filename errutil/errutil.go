@@ -33,8 +33,10 @@
 package errutil
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // ErrForbiddenMethod is returned when a prohibited method is called.
@@ -81,6 +83,26 @@ func Explain(err error, format string, args ...any) error {
 	return fmt.Errorf("%s: %w", msg, err)
 }
 
+// ExplainCtx behaves like Explain, but when ctx is done it appends the
+// cancellation cause to the explanation, so that failures caused by a
+// cancelled or expired context stand out from genuine ones:
+//
+//	"failed to query user (context deadline exceeded): i/o timeout"
+//
+// The cause is wrapped alongside err, so errors.Is(result, context.Canceled)
+// or errors.Is(result, context.DeadlineExceeded) reports true.
+func ExplainCtx(ctx context.Context, err error, format string, args ...any) error {
+	cause := context.Cause(ctx)
+	if cause == nil {
+		return Explain(err, format, args...)
+	}
+	msg := fmt.Sprintf(format, args...)
+	if err == nil {
+		return fmt.Errorf("%s (%w)", msg, cause)
+	}
+	return fmt.Errorf("%s (%w): %w", msg, cause, err)
+}
+
 // Stack wraps an existing error by adding *path context* —
 // an indicator of where the error has traveled in the call chain.
 //
@@ -113,3 +135,56 @@ func Stack(err error, format string, args ...any) error {
 	msg := fmt.Sprintf(format, args...)
 	return fmt.Errorf("%s >> %w", msg, err)
 }
+
+// Tree renders the unwrap chain of err as an indented multi-line tree,
+// one wrapping layer per line. Each nested line is prefixed with the kind
+// of link to its parent: ":" for explanatory wrapping (Explain) and ">>"
+// for path wrapping (Stack).
+//
+// Example:
+//
+//	err := errors.New("file not found")
+//	err = errutil.Explain(err, "read config")
+//	err = errutil.Stack(err, "LoadConfig")
+//	err = errutil.Explain(err, "init failed")
+//	fmt.Println(errutil.Tree(err))
+//
+// Output:
+//
+//	init failed
+//	└─ : LoadConfig
+//	   └─ >> read config
+//	      └─ : file not found
+//
+// Walking stops at the first error whose message cannot be split into
+// its own part and the message of the wrapped error; that error is shown
+// with its full message. Tree returns "" for a nil error.
+func Tree(err error) string {
+	var sb strings.Builder
+	link := ""
+	for depth := 0; err != nil; depth++ {
+		msg := err.Error()
+		inner := errors.Unwrap(err)
+		nextLink := ""
+		if inner != nil {
+			s := inner.Error()
+			if m, ok := strings.CutSuffix(msg, ": "+s); ok {
+				msg, nextLink = m, ":"
+			} else if m, ok = strings.CutSuffix(msg, " >> "+s); ok {
+				msg, nextLink = m, ">>"
+			} else {
+				inner = nil
+			}
+		}
+		if depth > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(strings.Repeat("   ", depth-1))
+			sb.WriteString("└─ ")
+			sb.WriteString(link)
+			sb.WriteString(" ")
+		}
+		sb.WriteString(msg)
+		err, link = inner, nextLink
+	}
+	return sb.String()
+}
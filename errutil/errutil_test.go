@@ -17,7 +17,9 @@
 package errutil
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -71,6 +73,51 @@ func TestExplain(t *testing.T) {
 	})
 }
 
+func TestExplainCtx(t *testing.T) {
+	t.Run("live context", func(t *testing.T) {
+		originalErr := errors.New("original error")
+		err := ExplainCtx(t.Context(), originalErr, "query %s", "user")
+		expected := "query user: original error"
+		if err.Error() != expected {
+			t.Errorf("expected error %q, but got %q", expected, err.Error())
+		}
+		if !errors.Is(err, originalErr) {
+			t.Errorf("expected error to wrap %q, but it did not", originalErr)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		originalErr := errors.New("original error")
+		err := ExplainCtx(ctx, originalErr, "query %s", "user")
+		expected := "query user (context canceled): original error"
+		if err.Error() != expected {
+			t.Errorf("expected error %q, but got %q", expected, err.Error())
+		}
+		if !errors.Is(err, originalErr) {
+			t.Errorf("expected error to wrap %q, but it did not", originalErr)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected error to wrap %q, but it did not", context.Canceled)
+		}
+	})
+
+	t.Run("cancelled context with cause and nil error", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(t.Context())
+		cause := errors.New("shutting down")
+		cancel(cause)
+		err := ExplainCtx(ctx, nil, "query user")
+		expected := "query user (shutting down)"
+		if err.Error() != expected {
+			t.Errorf("expected error %q, but got %q", expected, err.Error())
+		}
+		if !errors.Is(err, cause) {
+			t.Errorf("expected error to wrap %q, but it did not", cause)
+		}
+	})
+}
+
 func TestStack(t *testing.T) {
 	t.Run("nil error", func(t *testing.T) {
 		err := Stack(nil, "%s", "wrapped error")
@@ -120,3 +167,59 @@ func TestStack(t *testing.T) {
 		}
 	})
 }
+
+func TestTree(t *testing.T) {
+	t.Run("nil error", func(t *testing.T) {
+		if s := Tree(nil); s != "" {
+			t.Errorf("expected empty tree, but got %q", s)
+		}
+	})
+
+	t.Run("single error", func(t *testing.T) {
+		s := Tree(errors.New("base error"))
+		expected := "base error"
+		if s != expected {
+			t.Errorf("expected tree %q, but got %q", expected, s)
+		}
+	})
+
+	t.Run("mixed chain", func(t *testing.T) {
+		err := errors.New("file not found")
+		err = Explain(err, "read config")
+		err = Stack(err, "LoadConfig")
+		err = Explain(err, "init failed")
+		s := Tree(err)
+		expected := "init failed\n" +
+			"└─ : LoadConfig\n" +
+			"   └─ >> read config\n" +
+			"      └─ : file not found"
+		if s != expected {
+			t.Errorf("expected tree %q, but got %q", expected, s)
+		}
+	})
+
+	t.Run("jsonflow-style path", func(t *testing.T) {
+		err := Explain(nil, "invalid JSON: expected number but got `x`")
+		err = Stack(err, "[2]")
+		err = Stack(err, "[0]")
+		s := Tree(err)
+		expected := "[0]\n" +
+			"└─ >> [2]\n" +
+			"   └─ >> invalid JSON: expected number but got `x`"
+		if s != expected {
+			t.Errorf("expected tree %q, but got %q", expected, s)
+		}
+	})
+
+	t.Run("foreign wrapping", func(t *testing.T) {
+		err := errors.New("base error")
+		err = fmt.Errorf("failed (%w) twice", err)
+		err = Stack(err, "Run")
+		s := Tree(err)
+		expected := "Run\n" +
+			"└─ >> failed (base error) twice"
+		if s != expected {
+			t.Errorf("expected tree %q, but got %q", expected, s)
+		}
+	})
+}
@@ -20,6 +20,10 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/lvan100/golib/errutil"
 )
 
 // FuncName returns the function name for a given function.
@@ -47,3 +51,74 @@ func FileLine(fn any) (file string, line int, fnName string) {
 	s = strings.TrimRight(s, "-fm")
 	return file, line, s
 }
+
+// Once returns a function that calls fn on its first invocation and
+// returns the cached result on every later one. It is safe for concurrent
+// use; concurrent first callers block until fn returns.
+func Once[T any](fn func() T) func() T {
+	return sync.OnceValue(fn)
+}
+
+// OnceErr returns a function that calls fn until it succeeds, and then
+// returns the cached value on every later invocation. A failed call caches
+// nothing, so the next invocation calls fn again. It is safe for concurrent
+// use; calls to fn never overlap.
+func OnceErr[T any](fn func() (T, error)) func() (T, error) {
+	var (
+		mutex sync.Mutex
+		done  bool
+		value T
+	)
+	return func() (T, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if done {
+			return value, nil
+		}
+		v, err := fn()
+		if err != nil {
+			return v, err
+		}
+		value, done = v, true
+		return value, nil
+	}
+}
+
+// Throttle returns a function that invokes fn at most once per interval d.
+// The first call runs fn immediately and starts the interval; calls made
+// before the interval elapses are dropped, not deferred. It is safe for
+// concurrent use, and fn runs on the calling goroutine.
+func Throttle(d time.Duration, fn func()) func() {
+	var (
+		mutex sync.Mutex
+		next  time.Time
+	)
+	return func() {
+		mutex.Lock()
+		now := time.Now()
+		if now.Before(next) {
+			mutex.Unlock()
+			return
+		}
+		next = now.Add(d)
+		mutex.Unlock()
+		fn()
+	}
+}
+
+// RecoverToError runs fn and converts any panic raised by it into an error.
+// It returns nil if fn completes normally. A panic with an error value is
+// wrapped so that errors.Is and errors.As still match the original error.
+func RecoverToError(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = errutil.Explain(e, "panic recovered")
+				return
+			}
+			err = errutil.Explain(nil, "panic recovered: %v", r)
+		}
+	}()
+	fn()
+	return nil
+}
@@ -17,8 +17,12 @@
 package funcutil_test
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/lvan100/golib/funcutil"
 	"github.com/lvan100/golib/testing/assert"
@@ -53,25 +57,25 @@ func TestFileLine(t *testing.T) {
 		{
 			fnNoArgs,
 			"funcutil/funcutil_test.go",
-			36,
+			40,
 			"funcutil_test.fnNoArgs",
 		},
 		{
 			fnWithArgs,
 			"funcutil/funcutil_test.go",
-			38,
+			42,
 			"funcutil_test.fnWithArgs",
 		},
 		{
 			(*receiver).ptrFnNoArgs,
 			"funcutil/funcutil_test.go",
-			42,
+			46,
 			"funcutil_test.(*receiver).ptrFnNoArgs",
 		},
 		{
 			(*receiver).ptrFnWithArgs,
 			"funcutil/funcutil_test.go",
-			44,
+			48,
 			"funcutil_test.(*receiver).ptrFnWithArgs",
 		},
 	}
@@ -82,3 +86,109 @@ func TestFileLine(t *testing.T) {
 		assert.String(t, file).HasSuffix(c.file, fmt.Sprint(i))
 	}
 }
+
+func TestOnce(t *testing.T) {
+	count := 0
+	fn := funcutil.Once(func() int {
+		count++
+		return 42
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(10)
+	for range 10 {
+		go func() {
+			defer wg.Done()
+			assert.That(t, fn()).Equal(42)
+		}()
+	}
+	wg.Wait()
+
+	assert.That(t, fn()).Equal(42)
+	assert.That(t, count).Equal(1)
+}
+
+func TestOnceErr(t *testing.T) {
+	count := 0
+	fn := funcutil.OnceErr(func() (string, error) {
+		count++
+		if count < 3 {
+			return "", fmt.Errorf("attempt %d failed", count)
+		}
+		return "ok", nil
+	})
+
+	_, err := fn()
+	assert.Error(t, err).String("attempt 1 failed")
+	_, err = fn()
+	assert.Error(t, err).String("attempt 2 failed")
+
+	for range 3 {
+		v, err := fn()
+		assert.That(t, err).Nil()
+		assert.That(t, v).Equal("ok")
+	}
+	assert.That(t, count).Equal(3)
+
+	errFailed := errors.New("failed")
+	fail := funcutil.OnceErr(func() (int, error) { return 0, errFailed })
+	_, err = fail()
+	assert.Error(t, err).Is(errFailed)
+}
+
+func TestThrottle(t *testing.T) {
+	t.Run("concurrent burst", func(t *testing.T) {
+		var count atomic.Int32
+		fn := funcutil.Throttle(time.Hour, func() {
+			count.Add(1)
+		})
+
+		var wg sync.WaitGroup
+		wg.Add(100)
+		for range 100 {
+			go func() {
+				defer wg.Done()
+				fn()
+			}()
+		}
+		wg.Wait()
+		assert.That(t, count.Load()).Equal(int32(1))
+	})
+
+	t.Run("after interval", func(t *testing.T) {
+		var count int
+		fn := funcutil.Throttle(200*time.Millisecond, func() {
+			count++
+		})
+
+		fn()
+		fn()
+		assert.That(t, count).Equal(1)
+
+		time.Sleep(300 * time.Millisecond)
+		fn()
+		fn()
+		assert.That(t, count).Equal(2)
+	})
+}
+
+func TestRecoverToError(t *testing.T) {
+	t.Run("normal return", func(t *testing.T) {
+		called := false
+		err := funcutil.RecoverToError(func() { called = true })
+		assert.That(t, err).Nil()
+		assert.That(t, called).True()
+	})
+
+	t.Run("panic with error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := funcutil.RecoverToError(func() { panic(errBoom) })
+		assert.Error(t, err).String("panic recovered: boom")
+		assert.Error(t, err).Is(errBoom)
+	})
+
+	t.Run("panic with non-error", func(t *testing.T) {
+		err := funcutil.RecoverToError(func() { panic(42) })
+		assert.Error(t, err).String("panic recovered: 42")
+	})
+}
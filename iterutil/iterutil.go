@@ -16,6 +16,13 @@
 
 package iterutil
 
+import (
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
 // Times executes the function 'fn' exactly 'count' times.
 // Used to eliminate deferred execution under standard for loops.
 func Times(count int, fn func(i int)) {
@@ -57,3 +64,76 @@ func stepRangesBackward(start, end, step int, fn func(i int)) {
 		fn(i)
 	}
 }
+
+// MapSeq returns a sequence that yields fn applied to each element of seq.
+// It is lazy: fn is called only as elements are consumed.
+func MapSeq[T, U any](seq iter.Seq[T], fn func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(fn(v)) {
+				return
+			}
+		}
+	}
+}
+
+// FilterSeq returns a sequence that yields the elements of seq for which
+// pred returns true. It is lazy: pred is called only as elements are consumed.
+func FilterSeq[T any](seq iter.Seq[T], pred func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if pred(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// MapParallel applies fn to each element of s concurrently, using at most
+// 'workers' goroutines, and returns the results in the order of s.
+// If workers <= 0, it defaults to runtime.GOMAXPROCS(0).
+//
+// If fn returns an error, MapParallel stops handing out the remaining
+// elements, waits for the calls already running, and returns the first
+// error encountered with a nil result.
+func MapParallel[T, U any](s []T, workers int, fn func(T) (U, error)) ([]U, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(s))
+
+	var (
+		wg       sync.WaitGroup
+		next     atomic.Int64
+		stopped  atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	r := make([]U, len(s))
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for !stopped.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(s) {
+					return
+				}
+				v, err := fn(s[i])
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					stopped.Store(true)
+					return
+				}
+				r[i] = v
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return r, nil
+}
@@ -17,7 +17,13 @@
 package iterutil
 
 import (
+	"errors"
+	"iter"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/lvan100/golib/testing/assert"
 )
@@ -183,3 +189,114 @@ func TestStepRanges(t *testing.T) {
 		assert.That(t, arr).Equal([]int{10, 7, 4, 1})
 	})
 }
+
+// seqOf returns a sequence of the integers 0 to n-1 and counts how many
+// elements have been consumed.
+func seqOf(n int, consumed *int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := range n {
+			*consumed++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func TestMapSeq(t *testing.T) {
+	t.Run("map elements", func(t *testing.T) {
+		seq := MapSeq(slices.Values([]int{1, 2, 3}), func(i int) string {
+			return string(rune('a' + i))
+		})
+		assert.That(t, slices.Collect(seq)).Equal([]string{"b", "c", "d"})
+	})
+
+	t.Run("empty sequence", func(t *testing.T) {
+		seq := MapSeq(slices.Values([]int(nil)), func(i int) int { return i })
+		assert.That(t, slices.Collect(seq)).Nil()
+	})
+}
+
+func TestFilterSeq(t *testing.T) {
+	t.Run("filter and map chain", func(t *testing.T) {
+		var consumed int
+		even := FilterSeq(seqOf(10, &consumed), func(i int) bool { return i%2 == 0 })
+		squares := MapSeq(even, func(i int) int { return i * i })
+		assert.That(t, consumed).Equal(0)
+		assert.That(t, slices.Collect(squares)).Equal([]int{0, 4, 16, 36, 64})
+		assert.That(t, consumed).Equal(10)
+	})
+
+	t.Run("early stop is lazy", func(t *testing.T) {
+		var consumed int
+		even := FilterSeq(seqOf(10, &consumed), func(i int) bool { return i%2 == 0 })
+		var arr []int
+		for v := range MapSeq(even, func(i int) int { return i * i }) {
+			arr = append(arr, v)
+			if len(arr) == 2 {
+				break
+			}
+		}
+		assert.That(t, arr).Equal([]int{0, 4})
+		assert.That(t, consumed).Equal(3)
+	})
+}
+
+func TestMapParallel(t *testing.T) {
+	t.Run("preserve order", func(t *testing.T) {
+		s := make([]int, 100)
+		for i := range s {
+			s[i] = i
+		}
+		r, err := MapParallel(s, 8, func(i int) (string, error) {
+			if i%3 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			return strconv.Itoa(i), nil
+		})
+		assert.That(t, err).Nil()
+		assert.That(t, len(r)).Equal(100)
+		for i, v := range r {
+			assert.That(t, v).Equal(strconv.Itoa(i))
+		}
+	})
+
+	t.Run("empty slice", func(t *testing.T) {
+		r, err := MapParallel([]int{}, 0, func(i int) (int, error) { return i, nil })
+		assert.That(t, err).Nil()
+		assert.That(t, r).Equal([]int{})
+	})
+
+	t.Run("worker bound", func(t *testing.T) {
+		var running, peak atomic.Int32
+		_, err := MapParallel(make([]int, 50), 3, func(i int) (int, error) {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return i, nil
+		})
+		assert.That(t, err).Nil()
+		assert.That(t, peak.Load() <= 3).True()
+		assert.That(t, peak.Load() > 1).True()
+	})
+
+	t.Run("first error cancels remaining work", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		var calls atomic.Int32
+		r, err := MapParallel(make([]int, 1000), 2, func(i int) (int, error) {
+			if calls.Add(1) == 5 {
+				return 0, errBoom
+			}
+			return i, nil
+		})
+		assert.Error(t, err).Is(errBoom)
+		assert.That(t, r).Nil()
+		assert.That(t, calls.Load() < 1000).True()
+	})
+}
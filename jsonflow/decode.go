@@ -17,9 +17,14 @@
 package jsonflow
 
 import (
+	"bytes"
 	"encoding/base64"
+	"io"
 	"math"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/lvan100/golib/errutil"
 	"github.com/lvan100/golib/jsonflow/internal/json"
@@ -29,11 +34,28 @@ const (
 	errFormatBoolean = "invalid JSON: expected boolean but got `%s`"
 	errFormatNumber  = "invalid JSON: expected number but got `%s`"
 	errFormatString  = "invalid JSON: expected string but got `%s`"
+	errFormatKey     = "invalid JSON: expected object key but got `%s`"
+
+	errFormatNumberString = "invalid JSON: expected number string but got `%s`"
 )
 
 // Decoder defines a streaming JSON decoder interface.
 type Decoder = json.Decoder
 
+// UnmarshalOptions is an interface that defines options for decoding JSON.
+type UnmarshalOptions interface {
+	JSONUnmarshalOptions(NotForPublicUse)
+}
+
+// MaxDepth limits the nesting depth of objects and arrays accepted by
+// DecodeObject, DecodeArray, DecodeMap and the decoders built on them,
+// guarding against stack exhaustion on maliciously deep input, in particular
+// into self-referential types whose DecodeJSON recurses. The default is 1000;
+// a value <= 0 disables the limit.
+type MaxDepth int
+
+func (MaxDepth) JSONUnmarshalOptions(NotForPublicUse) {}
+
 // ParseBool parses a JSON boolean token into a Go bool.
 // The input Kind must be 't' or 'f', otherwise an error is returned.
 func ParseBool(token string, k json.Kind) (bool, error) {
@@ -117,6 +139,118 @@ func DecodeIntKey[T ~int | ~int8 | ~int16 | ~int32 | ~int64](d Decoder) (T, erro
 	return DecodeValue(ParseIntKey[T], errFormatNumber)(d)
 }
 
+// ParseIntLax parses a JSON number or string token as integer type T, after
+// stripping a single leading '+' and '_' digit separators, so "+5" and
+// "1_000" are accepted. As in Go literals, each '_' must sit between two
+// digits. This deviates from JSON, which allows neither, and is
+// meant for lenient feeds only; since such values are not valid JSON numbers,
+// they can only reach the decoder as strings. ParseInt stays strict.
+func ParseIntLax[T ~int | ~int8 | ~int16 | ~int32 | ~int64](token string, k json.Kind) (T, error) {
+	if k != '0' && k != '"' {
+		return 0, errutil.Explain(nil, errFormatNumber, token)
+	}
+	s, err := laxDigits(token)
+	if err != nil {
+		return 0, err
+	}
+	return ParseInt[T](s, '0')
+}
+
+// DecodeIntLax reads the next JSON value as an integer of type T like
+// DecodeInt, but leniently, see ParseIntLax.
+func DecodeIntLax[T ~int | ~int8 | ~int16 | ~int32 | ~int64](d Decoder) (T, error) {
+	return DecodeValue(ParseIntLax[T], errFormatNumber)(d)
+}
+
+// laxDigits strips a single leading '+' and the '_' digit separators from s.
+func laxDigits(s string) (string, error) {
+	t, plus := strings.CutPrefix(s, "+")
+	if plus && (strings.HasPrefix(t, "+") || strings.HasPrefix(t, "-")) {
+		return "", errutil.Explain(nil, errFormatNumber, s)
+	}
+	if !strings.Contains(t, "_") {
+		return t, nil
+	}
+	b := make([]byte, 0, len(t))
+	for i := 0; i < len(t); i++ {
+		if t[i] != '_' {
+			b = append(b, t[i])
+			continue
+		}
+		// Like in Go literals, '_' may only separate two digits.
+		if i == 0 || i == len(t)-1 || !isDigit(t[i-1]) || !isDigit(t[i+1]) {
+			return "", errutil.Explain(nil, errFormatNumber, s)
+		}
+	}
+	return string(b), nil
+}
+
+// isDigit reports whether c is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// ParseIntString parses a JSON string token holding an integer, e.g. "123",
+// into integer type T, applying the same overflow checks as ParseInt.
+// The string content must be a valid JSON number, so forms strconv would
+// otherwise accept, such as "+5" or "1_0", are rejected.
+func ParseIntString[T ~int | ~int8 | ~int16 | ~int32 | ~int64](token string, k json.Kind) (T, error) {
+	if k != '"' || !isNumber(token) {
+		return 0, errutil.Explain(nil, errFormatNumberString, token)
+	}
+	return ParseInt[T](token, '0')
+}
+
+// isNumber reports whether s is a number according to the JSON grammar:
+// an optional minus sign, an integer part without leading zeros, and
+// optional fraction and exponent parts.
+func isNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		i = skipDigits(s, i)
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		j := skipDigits(s, i+1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		j := skipDigits(s, i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	return i == len(s)
+}
+
+// skipDigits returns the index of the first non-digit byte of s at or after i.
+func skipDigits(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// DecodeIntString reads the next JSON value as a string-encoded integer of type T.
+func DecodeIntString[T ~int | ~int8 | ~int16 | ~int32 | ~int64](d Decoder) (T, error) {
+	return DecodeValue(ParseIntString[T], errFormatNumberString)(d)
+}
+
 // OverflowUint checks whether a uint64 value exceeds the bounds of the target unsigned type T.
 func OverflowUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](v uint64) bool {
 	var z T
@@ -175,14 +309,37 @@ func DecodeUintKey[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](d Decoder) (T
 	return DecodeValue(ParseUintKey[T], errFormatNumber)(d)
 }
 
+// ParseUintLax parses a JSON number or string token as unsigned integer type T
+// leniently, accepting a single leading '+' and '_' digit separators.
+// See ParseIntLax for how this deviates from JSON.
+func ParseUintLax[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](token string, k json.Kind) (T, error) {
+	if k != '0' && k != '"' {
+		return 0, errutil.Explain(nil, errFormatNumber, token)
+	}
+	s, err := laxDigits(token)
+	if err != nil {
+		return 0, err
+	}
+	return ParseUint[T](s, '0')
+}
+
+// DecodeUintLax reads the next JSON value as an unsigned integer of type T
+// like DecodeUint, but leniently, see ParseUintLax.
+func DecodeUintLax[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](d Decoder) (T, error) {
+	return DecodeValue(ParseUintLax[T], errFormatNumber)(d)
+}
+
 // OverflowFloat checks whether a float64 value exceeds the bounds of the target float type T.
+// For float32, the value overflows when the conversion yields ±Inf. Values slightly beyond
+// math.MaxFloat32 that round to it are therefore accepted, while those rounding to ±Inf are not.
+// ±Inf itself overflows every float type.
 func OverflowFloat[T ~float32 | ~float64](v float64) bool {
 	var z T
 	switch any(z).(type) {
 	case float32:
-		return v > math.MaxFloat32 || v < -math.MaxFloat32
+		return math.IsInf(float64(float32(v)), 0)
 	}
-	return false
+	return math.IsInf(v, 0)
 }
 
 // ParseFloat parses a JSON number token into a float type T.
@@ -210,6 +367,22 @@ func DecodeFloatPtr[T ~float32 | ~float64](d Decoder) (*T, error) {
 	return DecodeValuePtr(ParseFloat[T], errFormatNumber)(d)
 }
 
+// ParseFloatString parses a JSON string token holding a number, e.g. "1.5",
+// into float type T, applying the same overflow checks as ParseFloat.
+// The string content must be a valid JSON number, so non-finite values like
+// "NaN" or "Inf" and Go-only forms like "0x1p4" are rejected.
+func ParseFloatString[T ~float32 | ~float64](token string, k json.Kind) (T, error) {
+	if k != '"' || !isNumber(token) {
+		return 0, errutil.Explain(nil, errFormatNumberString, token)
+	}
+	return ParseFloat[T](token, '0')
+}
+
+// DecodeFloatString reads the next JSON value as a string-encoded float of type T.
+func DecodeFloatString[T ~float32 | ~float64](d Decoder) (T, error) {
+	return DecodeValue(ParseFloatString[T], errFormatNumberString)(d)
+}
+
 // ParseString parses a JSON string token into a Go string.
 func ParseString(token string, k json.Kind) (string, error) {
 	if k != '"' {
@@ -228,6 +401,47 @@ func DecodeStringPtr(d Decoder) (*string, error) {
 	return DecodeValuePtr(ParseString, errFormatString)(d)
 }
 
+// DecodeStringInto reads the next JSON string and appends its unescaped
+// bytes to dst, returning the extended slice. Unlike DecodeString, it does
+// not allocate a Go string, which makes it suitable for hot paths where the
+// value is consumed immediately.
+//
+// Aliasing rules: the returned slice shares dst's backing array whenever dst
+// has enough spare capacity, so callers should keep using the returned slice
+// (e.g. `buf, err = DecodeStringInto(d, buf[:0])`) and must not hold on to
+// previous results across calls that reuse the same buffer. The result never
+// references the decoder's internal buffer and stays valid after further reads.
+func DecodeStringInto(d Decoder, dst []byte) ([]byte, error) {
+	if d.PeekKind() != '"' {
+		token, _, err := d.ReadToken()
+		if err != nil {
+			return dst, err
+		}
+		return dst, errutil.Explain(nil, errFormatString, token)
+	}
+	b, err := d.ReadValue()
+	if err != nil {
+		return dst, err
+	}
+	return appendUnquote(dst, b)
+}
+
+// ParseStringKey parses a JSON object key token into a Go string.
+func ParseStringKey(token string, k json.Kind) (string, error) {
+	if k != '"' {
+		return "", errutil.Explain(nil, errFormatKey, token)
+	}
+	return token, nil
+}
+
+// DecodeStringKey reads a JSON object key and returns it as a string.
+// It behaves like DecodeString for valid keys, but reports a key-specific
+// error if the next token is not a string, which guards against misuse of
+// the decoder at a non-key position.
+func DecodeStringKey(d Decoder) (string, error) {
+	return DecodeValue(ParseStringKey, errFormatKey)(d)
+}
+
 // ParseBytes parses a JSON string token as base64-encoded bytes.
 func ParseBytes(token string, k json.Kind) ([]byte, error) {
 	if k != '"' {
@@ -241,6 +455,91 @@ func DecodeBytes(d Decoder) ([]byte, error) {
 	return DecodeValue(ParseBytes, errFormatString)(d)
 }
 
+// DecodeBytesRaw reads the next JSON string and returns its unescaped content
+// as bytes. Unlike DecodeBytes, the content is taken as is and is not base64
+// decoded, for plain string fields that are wanted as []byte.
+func DecodeBytesRaw(d Decoder) ([]byte, error) {
+	b, err := DecodeStringInto(d, nil)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		b = []byte{}
+	}
+	return b, nil
+}
+
+// ParseTimeUnix parses a JSON number token holding integer Unix seconds into a UTC time.Time.
+func ParseTimeUnix(token string, k json.Kind) (time.Time, error) {
+	v, err := ParseInt[int64](token, k)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(v, 0).UTC(), nil
+}
+
+// DecodeTimeUnix reads the next JSON value and parses it as Unix seconds.
+func DecodeTimeUnix(d Decoder) (time.Time, error) {
+	return DecodeValue(ParseTimeUnix, errFormatNumber)(d)
+}
+
+// DecodeTimeUnixPtr reads the next JSON value and parses it as a pointer to a time in Unix seconds.
+// Returns nil if the JSON token is null.
+func DecodeTimeUnixPtr(d Decoder) (*time.Time, error) {
+	return DecodeValuePtr(ParseTimeUnix, errFormatNumber)(d)
+}
+
+// ParseTimeKey parses a JSON object key in RFC 3339 format into a time.Time.
+// The time is converted to UTC, so equal instants make equal map keys.
+func ParseTimeKey(token string, _ json.Kind) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, token)
+	if err != nil {
+		return time.Time{}, errutil.Explain(err, "invalid JSON: expected RFC 3339 time key but got `%s`", token)
+	}
+	return t.UTC(), nil
+}
+
+// DecodeTimeKey reads a JSON object key and parses it as an RFC 3339 time,
+// for decoding maps keyed by time with DecodeMap.
+func DecodeTimeKey(d Decoder) (time.Time, error) {
+	return DecodeValue(ParseTimeKey, errFormatKey)(d)
+}
+
+// ParseUnixTimeKey parses a JSON object key holding integer Unix seconds into a UTC time.Time.
+func ParseUnixTimeKey(token string, k json.Kind) (time.Time, error) {
+	v, err := ParseIntKey[int64](token, k)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(v, 0).UTC(), nil
+}
+
+// DecodeUnixTimeKey reads a JSON object key and parses it as Unix seconds,
+// for decoding maps keyed by time with DecodeMap.
+func DecodeUnixTimeKey(d Decoder) (time.Time, error) {
+	return DecodeValue(ParseUnixTimeKey, errFormatKey)(d)
+}
+
+// ParseTimeUnixMilli parses a JSON number token holding integer Unix milliseconds into a UTC time.Time.
+func ParseTimeUnixMilli(token string, k json.Kind) (time.Time, error) {
+	v, err := ParseInt[int64](token, k)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(v).UTC(), nil
+}
+
+// DecodeTimeUnixMilli reads the next JSON value and parses it as Unix milliseconds.
+func DecodeTimeUnixMilli(d Decoder) (time.Time, error) {
+	return DecodeValue(ParseTimeUnixMilli, errFormatNumber)(d)
+}
+
+// DecodeTimeUnixMilliPtr reads the next JSON value and parses it as a pointer to a time in Unix milliseconds.
+// Returns nil if the JSON token is null.
+func DecodeTimeUnixMilliPtr(d Decoder) (*time.Time, error) {
+	return DecodeValuePtr(ParseTimeUnixMilli, errFormatNumber)(d)
+}
+
 // Object represents a JSON-mappable object that supports streaming decoding.
 type Object interface {
 	// DecodeJSON reads JSON data from the Decoder and populates the object.
@@ -273,6 +572,237 @@ func DecodeObjectEnd(d Decoder) error {
 	return nil
 }
 
+// RequiredFields tracks which required fields of an object have been decoded,
+// so that DecodeJSON implementations can declare them up front instead of
+// keeping one flag per field:
+//
+//	required := NewRequiredFields("Int", "Name")
+//	for d.PeekKind() != '}' {
+//		...
+//		case hashInt:
+//			if b.Int, err = DecodeInt[int](d); err != nil {
+//				return err
+//			}
+//			required.Found("Int")
+//		...
+//	}
+//	if err := DecodeObjectEnd(d); err != nil {
+//		return err
+//	}
+//	return required.Check()
+type RequiredFields struct {
+	names []string
+	found []bool
+}
+
+// NewRequiredFields creates a tracker for the given required field names.
+func NewRequiredFields(names ...string) *RequiredFields {
+	return &RequiredFields{names: names, found: make([]bool, len(names))}
+}
+
+// Found marks the named field as decoded. Names that were not declared
+// as required are ignored.
+func (r *RequiredFields) Found(name string) {
+	for i, s := range r.names {
+		if s == name {
+			r.found[i] = true
+			return
+		}
+	}
+}
+
+// Check returns an error naming the first required field, in declaration
+// order, that has not been marked as found.
+func (r *RequiredFields) Check() error {
+	for i, ok := range r.found {
+		if !ok {
+			return errutil.Explain(nil, "missing required field %s", r.names[i])
+		}
+	}
+	return nil
+}
+
+// CaptureUnknown reads the value of an unrecognized object key as raw JSON.
+// It is meant for the default case of a DecodeJSON key switch, so that
+// unknown fields can be preserved instead of skipped:
+//
+//	default:
+//		if o.Unknown[key], err = CaptureUnknown(d, key); err != nil {
+//			return err
+//		}
+//
+// The returned bytes are a copy and remain valid after further decoding.
+func CaptureUnknown(d Decoder, key string) (RawMessage, error) {
+	b, err := d.ReadValue()
+	if err != nil {
+		return nil, errutil.Stack(err, "unknown field %s", key)
+	}
+	return bytes.Clone(b), nil
+}
+
+// GetByPath returns the raw JSON value found at path within raw, without
+// decoding the rest of the document. The path is a sequence of object keys
+// separated by dots and array indexes in brackets, e.g. "a.b[2].c"; an empty
+// path selects raw itself. The returned bytes are a copy of the subtree.
+// Missing keys, out-of-range indexes and type mismatches return an error
+// naming the part of the path that was resolved. The rest of raw after the
+// subtree is not decoded, but it is scanned to check that raw as a whole is
+// valid JSON.
+func GetByPath(raw RawMessage, path string) (RawMessage, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(bytes.NewReader(raw))
+	for i, seg := range segments {
+		at := joinPath(segments[:i])
+		token, k, err := d.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+		if seg.key != nil {
+			if k != '{' {
+				return nil, errutil.Explain(nil, "path `%s`: expected object but got `%s`", at, token)
+			}
+			if err = findKey(d, *seg.key); err != nil {
+				return nil, errutil.Explain(err, "path `%s`", at)
+			}
+			continue
+		}
+		if k != '[' {
+			return nil, errutil.Explain(nil, "path `%s`: expected array but got `%s`", at, token)
+		}
+		if err = findIndex(d, seg.index); err != nil {
+			return nil, errutil.Explain(err, "path `%s`", at)
+		}
+	}
+	b, err := d.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.Clone(b)
+	if err = skipToEOF(d, len(segments)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// skipToEOF consumes the rest of the input after a value nested depth
+// levels deep, checking that it closes the enclosing objects and arrays
+// and that nothing but whitespace follows the top-level value.
+func skipToEOF(d Decoder, depth int) error {
+	for depth > 0 {
+		switch d.PeekKind() {
+		case '}', ']':
+			_, _, _ = d.ReadToken()
+			depth--
+		default:
+			if err := d.SkipValue(); err != nil {
+				return err
+			}
+		}
+	}
+	token, _, err := d.ReadToken()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return errutil.Explain(nil, "invalid JSON: unexpected `%s` after top-level value", token)
+}
+
+// pathSegment is either an object key or an array index of a GetByPath path.
+type pathSegment struct {
+	key   *string
+	index int
+}
+
+// parsePath splits a dot/bracket path like "a.b[2].c" into segments.
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	for s := path; s != ""; {
+		switch {
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, errutil.Explain(nil, "invalid path `%s`", path)
+			}
+			i, err := strconv.Atoi(s[1:end])
+			if err != nil || i < 0 {
+				return nil, errutil.Explain(nil, "invalid path `%s`", path)
+			}
+			segments = append(segments, pathSegment{index: i})
+			s = s[end+1:]
+			if s != "" && s[0] != '.' && s[0] != '[' {
+				return nil, errutil.Explain(nil, "invalid path `%s`", path)
+			}
+		case s[0] == '.' && len(segments) > 0:
+			s = s[1:]
+			if s == "" || s[0] == '.' || s[0] == '[' {
+				return nil, errutil.Explain(nil, "invalid path `%s`", path)
+			}
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, errutil.Explain(nil, "invalid path `%s`", path)
+			}
+			key := s[:end]
+			segments = append(segments, pathSegment{key: &key})
+			s = s[end:]
+		}
+	}
+	return segments, nil
+}
+
+// joinPath formats path segments back into their dot/bracket form.
+func joinPath(segments []pathSegment) string {
+	var sb strings.Builder
+	for _, seg := range segments {
+		if seg.key == nil {
+			sb.WriteString("[" + strconv.Itoa(seg.index) + "]")
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(*seg.key)
+	}
+	return sb.String()
+}
+
+// findKey advances d, positioned just inside an object, to the value of key.
+func findKey(d Decoder, key string) error {
+	for d.PeekKind() != '}' {
+		s, err := DecodeStringKey(d)
+		if err != nil {
+			return err
+		}
+		if s == key {
+			return nil
+		}
+		if err = d.SkipValue(); err != nil {
+			return err
+		}
+	}
+	return errutil.Explain(nil, "key `%s` not found", key)
+}
+
+// findIndex advances d, positioned just inside an array, to the element at index.
+func findIndex(d Decoder, index int) error {
+	for i := 0; d.PeekKind() != ']'; i++ {
+		if i == index {
+			return nil
+		}
+		if err := d.SkipValue(); err != nil {
+			return err
+		}
+	}
+	return errutil.Explain(nil, "index %d out of range", index)
+}
+
 // DecodeAny decodes the next JSON value (scalar, object, or array)
 // into a Go value using Decoder.Unmarshal.
 func DecodeAny(d Decoder) (any, error) {
@@ -287,6 +817,53 @@ func DecodeAny(d Decoder) (any, error) {
 	return v, nil
 }
 
+// DecodeAnyTyped decodes the next JSON value like DecodeAny, but when useNumber
+// is true, numbers are decoded as json.Number instead of float64, mirroring
+// the UseNumber option of encoding/json. This keeps large integers inside
+// `any` trees from losing precision.
+func DecodeAnyTyped(d Decoder, useNumber bool) (any, error) {
+	if !useNumber {
+		return DecodeAny(d)
+	}
+	b, err := d.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err = unmarshalUseNumber(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// defaultMaxDepth is the nesting depth limit of decoders created by
+// NewDecoder without a MaxDepth option.
+const defaultMaxDepth = 1000
+
+// depthDecoder is implemented by decoders that track their nesting depth,
+// such as those created by NewDecoder. Other Decoder implementations are
+// not depth limited.
+type depthDecoder interface {
+	// StackDepth returns the number of objects and arrays the decoder
+	// is currently nested in; it is 0 at the top level.
+	StackDepth() int
+	// MaxStackDepth returns the nesting depth limit, or 0 for no limit.
+	MaxStackDepth() int
+}
+
+// checkDepth returns an error if entering the next object or array
+// would nest deeper than the decoder's depth limit.
+func checkDepth(d Decoder) error {
+	dd, ok := d.(depthDecoder)
+	if !ok {
+		return nil
+	}
+	if n := dd.MaxStackDepth(); n > 0 && dd.StackDepth() >= n {
+		return errutil.Explain(nil, "invalid JSON: exceeded max depth %d", n)
+	}
+	return nil
+}
+
 // DecodeValue parses a scalar JSON value (number, boolean, or string) using parseFn.
 // Returns an error if the next token is null or invalid.
 func DecodeValue[T any](
@@ -340,6 +917,9 @@ func DecodeValuePtr[T any](
 // DecodeObject decodes a JSON object into a struct that implements the Object interface.
 // Returns the zero value if the next token is null.
 // Internally calls DecodeJSON on the object to populate its fields.
+// Each object entered counts towards the decoder's MaxDepth, so input
+// nesting a self-referential type too deeply fails with an error instead
+// of recursing without bound.
 func DecodeObject[T Object](
 	newFn func() T,
 ) func(d Decoder) (T, error) {
@@ -350,6 +930,9 @@ func DecodeObject[T Object](
 			_, _, _ = d.ReadToken()
 			return v, nil
 		case '{':
+			if err := checkDepth(d); err != nil {
+				return v, err
+			}
 			v = newFn()
 			if err := v.DecodeJSON(d); err != nil {
 				return v, err
@@ -365,9 +948,127 @@ func DecodeObject[T Object](
 	}
 }
 
+// DecodeObjectOpt decodes a JSON object like DecodeObject, and additionally
+// reports whether a value was present: present is false only when the next
+// token is null. Combined with the key switch of DecodeJSON, which only runs
+// for keys that occur, this distinguishes an explicit null from an omitted
+// field, as needed for PATCH-style updates.
+func DecodeObjectOpt[T Object](
+	newFn func() T,
+) func(d Decoder) (T, bool, error) {
+	return func(d Decoder) (T, bool, error) {
+		present := d.PeekKind() != 'n'
+		v, err := DecodeObject(newFn)(d)
+		if err != nil {
+			return v, false, err
+		}
+		return v, present, nil
+	}
+}
+
+// DecodeObjectArray decodes a JSON array of objects, a shorthand for
+// DecodeArray(DecodeObject(newFn)). Returns nil if the next token is null;
+// null elements decode to the zero value of T.
+func DecodeObjectArray[T Object](
+	newFn func() T,
+) func(d Decoder) ([]T, error) {
+	return DecodeArray(DecodeObject(newFn))
+}
+
+// typeRegistry maps discriminator values to object constructors
+// for DecodeRegistered.
+var typeRegistry = struct {
+	sync.RWMutex
+	m map[string]func() Object
+}{m: make(map[string]func() Object)}
+
+// RegisterType registers newFn as the constructor of objects whose type field
+// holds name, for decoding with DecodeRegistered. Registering a name again
+// replaces its constructor. It is safe for concurrent use.
+func RegisterType(name string, newFn func() Object) {
+	typeRegistry.Lock()
+	defer typeRegistry.Unlock()
+	typeRegistry.m[name] = newFn
+}
+
+// DecodeRegistered decodes a JSON object whose concrete type is selected by
+// the string value of typeField, using the constructor registered for that
+// value with RegisterType. This supports heterogeneous arrays, e.g.
+// DecodeArray(DecodeRegistered("type")). The type field may appear anywhere
+// in the object and is passed to DecodeJSON along with the other fields.
+// Returns nil if the next token is null.
+//
+// To find the type field, the object is buffered and scanned before it is
+// decoded, so its bytes are read three times. Registered types nested inside
+// one another are buffered again at each level, which makes the cost grow
+// with the nesting depth; the decoder's MaxDepth still applies across levels.
+func DecodeRegistered(typeField string) func(d Decoder) (any, error) {
+	return func(d Decoder) (any, error) {
+		switch d.PeekKind() {
+		case 'n':
+			_, _, _ = d.ReadToken()
+			return nil, nil
+		case '{':
+			if err := checkDepth(d); err != nil {
+				return nil, err
+			}
+			b, err := d.ReadValue()
+			if err != nil {
+				return nil, err
+			}
+			name, err := findTypeField(b, typeField)
+			if err != nil {
+				return nil, err
+			}
+			typeRegistry.RLock()
+			newFn, ok := typeRegistry.m[name]
+			typeRegistry.RUnlock()
+			if !ok {
+				return nil, errutil.Explain(nil, "invalid JSON: unregistered type `%s`", name)
+			}
+			v := newFn()
+			if err = v.DecodeJSON(newSubDecoder(d, b)); err != nil {
+				return nil, err
+			}
+			return v, nil
+		default:
+			token, _, err := d.ReadToken()
+			if err != nil {
+				return nil, err
+			}
+			return nil, errutil.Explain(nil, "invalid JSON: expected `{` but got `%s`", token)
+		}
+	}
+}
+
+// findTypeField returns the string value of the member typeField
+// of the JSON object b.
+func findTypeField(b []byte, typeField string) (string, error) {
+	d := NewDecoder(bytes.NewReader(b))
+	if err := DecodeObjectBegin(d); err != nil {
+		return "", err
+	}
+	for d.PeekKind() != '}' {
+		key, err := DecodeStringKey(d)
+		if err != nil {
+			return "", err
+		}
+		if key == typeField {
+			return DecodeString(d)
+		}
+		if err = d.SkipValue(); err != nil {
+			return "", err
+		}
+	}
+	return "", errutil.Explain(nil, "invalid JSON: missing type field %s", typeField)
+}
+
 // DecodeArray decodes a JSON array of arbitrary type.
 // parseFn is used to parse each element of the array.
-// Returns nil if the next token is null.
+// Returns nil if the next token is null. Errors from parseFn
+// are prefixed with the element index, e.g. "[2] >> ...", and
+// malformed array structure, such as a trailing comma or a missing
+// `]`, is explained with the number of elements read so far.
 func DecodeArray[T any](
 	parseFn func(d Decoder) (T, error),
 ) func(d Decoder) ([]T, error) {
@@ -377,15 +1078,21 @@ func DecodeArray[T any](
 			_, _, _ = d.ReadToken()
 			return nil, nil
 		case '[':
+			if err := checkDepth(d); err != nil {
+				return nil, err
+			}
 			_, _, _ = d.ReadToken()
 			v := make([]T, 0)
 			for {
-				if d.PeekKind() == ']' {
+				if k := d.PeekKind(); k == ']' {
 					break
+				} else if k == json.InvalidKind {
+					_, _, err := d.ReadToken()
+					return nil, errutil.Explain(err, "while decoding array element %d", len(v))
 				}
 				i, err := parseFn(d)
 				if err != nil {
-					return nil, err
+					return nil, errutil.Stack(err, "[%d]", len(v))
 				}
 				v = append(v, i)
 			}
@@ -401,9 +1108,66 @@ func DecodeArray[T any](
 	}
 }
 
+// DecodeIntArray decodes a JSON array of integers of type T. It behaves
+// exactly like DecodeArray(DecodeInt[T]), including for null, empty and
+// error cases, but parses elements directly from the token stream.
+func DecodeIntArray[T ~int | ~int8 | ~int16 | ~int32 | ~int64](d Decoder) ([]T, error) {
+	return decodeNumberArray(d, ParseInt[T])
+}
+
+// DecodeFloatArray decodes a JSON array of floats of type T. It behaves
+// exactly like DecodeArray(DecodeFloat[T]), including for null, empty and
+// error cases, but parses elements directly from the token stream.
+func DecodeFloatArray[T ~float32 | ~float64](d Decoder) ([]T, error) {
+	return decodeNumberArray(d, ParseFloat[T])
+}
+
+// decodeNumberArray decodes a JSON array of numbers, reading each element
+// with a single ReadToken instead of going through a per-element decoder.
+func decodeNumberArray[T any](d Decoder, parseFn func(string, json.Kind) (T, error)) ([]T, error) {
+	switch d.PeekKind() {
+	case 'n':
+		_, _, _ = d.ReadToken()
+		return nil, nil
+	case '[':
+		if err := checkDepth(d); err != nil {
+			return nil, err
+		}
+		_, _, _ = d.ReadToken()
+		v := make([]T, 0)
+		for {
+			token, k, err := d.ReadToken()
+			if err != nil {
+				return nil, errutil.Explain(err, "while decoding array element %d", len(v))
+			}
+			switch k {
+			case ']':
+				return v, nil
+			case '0':
+				i, err := parseFn(token, k)
+				if err != nil {
+					return nil, errutil.Stack(err, "[%d]", len(v))
+				}
+				v = append(v, i)
+			default:
+				err = errutil.Explain(nil, errFormatNumber, token)
+				return nil, errutil.Stack(err, "[%d]", len(v))
+			}
+		}
+	default:
+		token, _, err := d.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+		return nil, errutil.Explain(nil, "invalid JSON: expected `[` but got `%s`", token)
+	}
+}
+
 // DecodeMap decodes a JSON object into a Go map.
 // parseKeyFn and parseValFn are used to parse each key and value.
-// Returns nil if the next token is null.
+// Returns nil if the next token is null. Malformed object structure,
+// such as a trailing comma or a missing `}`, is explained with the
+// number of entries read so far.
 func DecodeMap[K comparable, V any](
 	parseKeyFn func(d Decoder) (K, error),
 	parseValFn func(d Decoder) (V, error),
@@ -414,16 +1178,78 @@ func DecodeMap[K comparable, V any](
 			_, _, _ = d.ReadToken()
 			return nil, nil
 		case '{':
+			if err := checkDepth(d); err != nil {
+				return nil, err
+			}
 			_, _, _ = d.ReadToken()
 			m := make(map[K]V)
-			for {
-				if d.PeekKind() == '}' {
+			for n := 0; ; n++ {
+				if k := d.PeekKind(); k == '}' {
+					break
+				} else if k == json.InvalidKind {
+					_, _, err := d.ReadToken()
+					return nil, errutil.Explain(err, "while decoding map entry %d", n)
+				}
+				key, err := parseKeyFn(d)
+				if err != nil {
+					return nil, err
+				}
+				val, err := parseValFn(d)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = val
+			}
+			_, _, _ = d.ReadToken()
+			return m, nil
+		default:
+			token, _, err := d.ReadToken()
+			if err != nil {
+				return nil, err
+			}
+			return nil, errutil.Explain(nil, "invalid JSON: expected `{` but got `%s`", token)
+		}
+	}
+}
+
+// DecodeMapFiltered decodes a JSON object into a Go map like DecodeMap, but
+// parses values only for keys contained in want; the values of other keys are
+// skipped with SkipValue without being parsed, which is faster than decoding
+// the whole map and filtering afterward. Skipped values must still be
+// well-formed JSON. Returns nil if the next token is null.
+func DecodeMapFiltered[K comparable, V any](
+	parseKeyFn func(d Decoder) (K, error),
+	parseValFn func(d Decoder) (V, error),
+	want map[K]bool,
+) func(d Decoder) (map[K]V, error) {
+	return func(d Decoder) (map[K]V, error) {
+		switch d.PeekKind() {
+		case 'n':
+			_, _, _ = d.ReadToken()
+			return nil, nil
+		case '{':
+			if err := checkDepth(d); err != nil {
+				return nil, err
+			}
+			_, _, _ = d.ReadToken()
+			m := make(map[K]V, len(want))
+			for n := 0; ; n++ {
+				if k := d.PeekKind(); k == '}' {
 					break
+				} else if k == json.InvalidKind {
+					_, _, err := d.ReadToken()
+					return nil, errutil.Explain(err, "while decoding map entry %d", n)
 				}
 				key, err := parseKeyFn(d)
 				if err != nil {
 					return nil, err
 				}
+				if !want[key] {
+					if err = d.SkipValue(); err != nil {
+						return nil, err
+					}
+					continue
+				}
 				val, err := parseValFn(d)
 				if err != nil {
 					return nil, err
@@ -441,3 +1267,49 @@ func DecodeMap[K comparable, V any](
 		}
 	}
 }
+
+// DecodeSet decodes a JSON array and removes duplicate elements,
+// keeping the first occurrence of each. Returns nil if the next token is null.
+func DecodeSet[T comparable](
+	parseFn func(d Decoder) (T, error),
+) func(d Decoder) ([]T, error) {
+	return DecodeSetBy(parseFn, func(v T) T { return v })
+}
+
+// DecodeSetBy decodes a JSON array and removes elements whose key, as
+// derived by keyFn, was already seen, keeping the first occurrence.
+// This suits object elements that should be unique by an ID field.
+// Returns nil if the next token is null.
+func DecodeSetBy[T any, K comparable](
+	parseFn func(d Decoder) (T, error),
+	keyFn func(v T) K,
+) func(d Decoder) ([]T, error) {
+	return func(d Decoder) ([]T, error) {
+		arr, err := DecodeArray(parseFn)(d)
+		if err != nil || arr == nil {
+			return arr, err
+		}
+		seen := make(map[K]struct{}, len(arr))
+		v := arr[:0]
+		for _, x := range arr {
+			k := keyFn(x)
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			v = append(v, x)
+		}
+		clear(arr[len(v):])
+		return v, nil
+	}
+}
+
+// DecodeMultiMap decodes a JSON object whose values are arrays into a map of slices,
+// such as HTTP-header-like structures. parseKeyFn parses each key and parseValFn
+// parses each array element. Null and empty objects behave as in DecodeMap.
+func DecodeMultiMap[K comparable, V any](
+	parseKeyFn func(d Decoder) (K, error),
+	parseValFn func(d Decoder) (V, error),
+) func(d Decoder) (map[K][]V, error) {
+	return DecodeMap(parseKeyFn, DecodeArray(parseValFn))
+}
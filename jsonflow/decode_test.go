@@ -18,12 +18,14 @@ package jsonflow
 
 import (
 	"encoding/base64"
+	stdjson "encoding/json"
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/lvan100/golib/errutil"
 	"github.com/lvan100/golib/hashutil"
 	"github.com/lvan100/golib/testing/assert"
 )
@@ -418,6 +420,39 @@ func TestDecodeFloat(t *testing.T) {
 		_, err := DecodeFloat[float32](d)
 		assert.Error(t, err).String("invalid JSON: number out of range, got `680564693277057700000000000000000000000")
 	})
+
+	t.Run("Decode max float32", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(strconv.FormatFloat(math.MaxFloat32, 'g', -1, 64)))
+		result, err := DecodeFloat[float32](d)
+		assert.That(t, err).Nil()
+		assert.Number(t, result).Equal(math.MaxFloat32)
+	})
+
+	t.Run("Decode just above max float32", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("3.4028235e38"))
+		result, err := DecodeFloat[float32](d)
+		assert.That(t, err).Nil()
+		assert.Number(t, result).Equal(math.MaxFloat32)
+	})
+
+	t.Run("Decode float32 rounding to +Inf", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("3.5e38"))
+		_, err := DecodeFloat[float32](d)
+		assert.Error(t, err).String("invalid JSON: number out of range, got `3.5e38")
+	})
+
+	t.Run("Decode float32 rounding to -Inf", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("-3.5e38"))
+		_, err := DecodeFloat[float32](d)
+		assert.Error(t, err).String("invalid JSON: number out of range, got `-3.5e38")
+	})
+
+	t.Run("Decode large float64", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("3.5e38"))
+		result, err := DecodeFloat[float64](d)
+		assert.That(t, err).Nil()
+		assert.Number(t, result).Equal(3.5e38)
+	})
 }
 
 func TestDecodeFloatPtr(t *testing.T) {
@@ -467,6 +502,140 @@ func TestDecodeFloatPtr(t *testing.T) {
 	})
 }
 
+func TestDecodeNumberString(t *testing.T) {
+	t.Run("Decode int string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"123"`))
+		v, err := DecodeIntString[int](d)
+		assert.That(t, err).Nil()
+		assert.Number(t, v).Equal(123)
+	})
+
+	t.Run("Decode float string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"1.5"`))
+		v, err := DecodeFloatString[float64](d)
+		assert.That(t, err).Nil()
+		assert.Number(t, v).Equal(1.5)
+	})
+
+	t.Run("Decode invalid int string", func(t *testing.T) {
+		for _, s := range []string{"12a", "+5", "1_0", " 5", "05", "0x10", "", "-"} {
+			d := NewDecoder(strings.NewReader(strconv.Quote(s)))
+			_, err := DecodeIntString[int](d)
+			assert.Error(t, err).String("invalid JSON: expected number string but got `"+s+"`", s)
+		}
+	})
+
+	t.Run("Decode invalid float string", func(t *testing.T) {
+		for _, s := range []string{"abc", "NaN", "Inf", "-infinity", "0x1p4", "1_0", "+1.5", ".5", "1.", "1e", "1e+", "1.5 "} {
+			d := NewDecoder(strings.NewReader(strconv.Quote(s)))
+			_, err := DecodeFloatString[float64](d)
+			assert.Error(t, err).String("invalid JSON: expected number string but got `"+s+"`", s)
+		}
+	})
+
+	t.Run("Decode valid number forms", func(t *testing.T) {
+		for s, expect := range map[string]float64{"0": 0, "-0.5": -0.5, "1e3": 1000, "2.5E-1": 0.25, "-1E+2": -100} {
+			d := NewDecoder(strings.NewReader(strconv.Quote(s)))
+			v, err := DecodeFloatString[float64](d)
+			assert.That(t, err).Nil(s)
+			assert.Number(t, v).Equal(expect, s)
+		}
+	})
+
+	t.Run("Decode non-finite float string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"1e400"`))
+		_, err := DecodeFloatString[float64](d)
+		assert.Error(t, err).Matches("value out of range")
+	})
+
+	t.Run("Decode int string overflow", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"300"`))
+		_, err := DecodeIntString[int8](d)
+		assert.Error(t, err).Matches("number out of range")
+	})
+
+	t.Run("Decode float string overflow", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"3.5e38"`))
+		_, err := DecodeFloatString[float32](d)
+		assert.Error(t, err).Matches("number out of range")
+	})
+
+	t.Run("Decode bare number", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`123`))
+		_, err := DecodeIntString[int](d)
+		assert.Error(t, err).String("invalid JSON: expected number string but got `123`")
+	})
+
+	t.Run("Decode null", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`null`))
+		_, err := DecodeFloatString[float64](d)
+		assert.Error(t, err).String("invalid JSON: expected number string but got `null`")
+	})
+}
+
+func TestDecodeIntLax(t *testing.T) {
+	t.Run("Decode leading plus", func(t *testing.T) {
+		v, err := DecodeIntLax[int](NewDecoder(strings.NewReader(`"+5"`)))
+		assert.That(t, err).Nil()
+		assert.Number(t, v).Equal(5)
+
+		u, err := DecodeUintLax[uint](NewDecoder(strings.NewReader(`"+5"`)))
+		assert.That(t, err).Nil()
+		assert.Number(t, u).Equal(uint(5))
+	})
+
+	t.Run("Decode underscores", func(t *testing.T) {
+		v, err := DecodeIntLax[int](NewDecoder(strings.NewReader(`"-1_000"`)))
+		assert.That(t, err).Nil()
+		assert.Number(t, v).Equal(-1000)
+
+		u, err := DecodeUintLax[uint32](NewDecoder(strings.NewReader(`"+1_000_000"`)))
+		assert.That(t, err).Nil()
+		assert.Number(t, u).Equal(uint32(1000000))
+	})
+
+	t.Run("Decode plain number", func(t *testing.T) {
+		v, err := DecodeIntLax[int](NewDecoder(strings.NewReader(`42`)))
+		assert.That(t, err).Nil()
+		assert.Number(t, v).Equal(42)
+	})
+
+	t.Run("Decode double sign", func(t *testing.T) {
+		_, err := DecodeIntLax[int](NewDecoder(strings.NewReader(`"+-5"`)))
+		assert.Error(t, err).String("invalid JSON: expected number but got `+-5`")
+	})
+
+	t.Run("Decode misplaced underscores", func(t *testing.T) {
+		for _, s := range []string{"_1_", "_1", "1_", "1__0", "+_5", "-_5", "1_.5"} {
+			_, err := DecodeIntLax[int](NewDecoder(strings.NewReader(strconv.Quote(s))))
+			assert.Error(t, err).String("invalid JSON: expected number but got `"+s+"`", s)
+			_, err = DecodeUintLax[uint](NewDecoder(strings.NewReader(strconv.Quote(s))))
+			assert.Error(t, err).String("invalid JSON: expected number but got `"+s+"`", s)
+		}
+	})
+
+	t.Run("Decode overflow", func(t *testing.T) {
+		_, err := DecodeUintLax[uint8](NewDecoder(strings.NewReader(`"1_000"`)))
+		assert.Error(t, err).Contains("number out of range")
+	})
+
+	t.Run("Decode null", func(t *testing.T) {
+		_, err := DecodeIntLax[int](NewDecoder(strings.NewReader(`null`)))
+		assert.Error(t, err).String("invalid JSON: expected number but got `null`")
+	})
+
+	t.Run("Strict decoders reject lax forms", func(t *testing.T) {
+		_, err := DecodeInt[int](NewDecoder(strings.NewReader(`"+5"`)))
+		assert.Error(t, err).String("invalid JSON: expected number but got `+5`")
+		_, err = DecodeUint[uint](NewDecoder(strings.NewReader(`"1_000"`)))
+		assert.Error(t, err).String("invalid JSON: expected number but got `1_000`")
+		_, err = DecodeIntString[int](NewDecoder(strings.NewReader(`"1_000"`)))
+		assert.Error(t, err).String("invalid JSON: expected number string but got `1_000`")
+		_, err = DecodeInt[int](NewDecoder(strings.NewReader(`+5`)))
+		assert.Error(t, err).Contains("invalid character '+'")
+	})
+}
+
 func TestDecodeString(t *testing.T) {
 	t.Run("Decode simple string", func(t *testing.T) {
 		d := NewDecoder(strings.NewReader(`"hello"`))
@@ -530,6 +699,158 @@ func TestDecodeStringPtr(t *testing.T) {
 	})
 }
 
+func TestDecodeStringInto(t *testing.T) {
+	t.Run("Decode simple string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"hello"`))
+		result, err := DecodeStringInto(d, nil)
+		assert.That(t, err).Nil()
+		assert.String(t, string(result)).Equal("hello")
+	})
+
+	t.Run("Decode string with escape sequences", func(t *testing.T) {
+		expected := "hello\r\n\t\\\"/world 世界"
+		d := NewDecoder(strings.NewReader(`"hello\r\n\t\\\"\/world \u4e16\u754c"`))
+		result, err := DecodeStringInto(d, nil)
+		assert.That(t, err).Nil()
+		assert.String(t, string(result)).Equal(expected)
+	})
+
+	t.Run("Append to existing bytes", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"world"`))
+		result, err := DecodeStringInto(d, []byte("hello "))
+		assert.That(t, err).Nil()
+		assert.String(t, string(result)).Equal("hello world")
+	})
+
+	t.Run("Reuse buffer capacity", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`["first", "second"]`))
+		_, _, _ = d.ReadToken()
+		buf := make([]byte, 0, 64)
+		result, err := DecodeStringInto(d, buf)
+		assert.That(t, err).Nil()
+		assert.String(t, string(result)).Equal("first")
+		assert.That(t, &result[:1][0]).Same(&buf[:1][0])
+		result, err = DecodeStringInto(d, result[:0])
+		assert.That(t, err).Nil()
+		assert.String(t, string(result)).Equal("second")
+		assert.That(t, &result[:1][0]).Same(&buf[:1][0])
+	})
+
+	t.Run("Decode null", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		_, err := DecodeStringInto(d, nil)
+		assert.Error(t, err).String("invalid JSON: expected string but got `null`")
+	})
+
+	t.Run("Decode invalid type", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("123"))
+		_, err := DecodeStringInto(d, nil)
+		assert.Error(t, err).String("invalid JSON: expected string but got `123`")
+	})
+
+	t.Run("Decode empty input", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(""))
+		_, err := DecodeStringInto(d, nil)
+		assert.Error(t, err).String("EOF")
+	})
+}
+
+func BenchmarkDecodeString(b *testing.B) {
+	const s = `"hello\tworld, this is a moderately long JSON string value"`
+
+	b.Run("DecodeString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d := NewDecoder(strings.NewReader(s))
+			if _, err := DecodeString(d); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("DecodeStringInto", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 128)
+		for i := 0; i < b.N; i++ {
+			d := NewDecoder(strings.NewReader(s))
+			var err error
+			if buf, err = DecodeStringInto(d, buf[:0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDecodeStringKey(t *testing.T) {
+	t.Run("Decode object keys", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": 1, "b\u0021": 2}`))
+		result, err := DecodeMap(DecodeStringKey, DecodeInt[int])(d)
+		assert.That(t, err).Nil()
+		assert.Map(t, result).Equal(map[string]int{"a": 1, "b!": 2})
+	})
+
+	t.Run("Decode string token", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"key"`))
+		result, err := DecodeStringKey(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal("key")
+	})
+
+	t.Run("Decode number token", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("123"))
+		_, err := DecodeStringKey(d)
+		assert.Error(t, err).String("invalid JSON: expected object key but got `123`")
+	})
+
+	t.Run("Decode null token", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		_, err := DecodeStringKey(d)
+		assert.Error(t, err).String("invalid JSON: expected object key but got `null`")
+	})
+
+	t.Run("Decode object begin token", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("{}"))
+		_, err := DecodeStringKey(d)
+		assert.Error(t, err).String("invalid JSON: expected object key but got `{`")
+	})
+}
+
+func TestDecodeBytesRaw(t *testing.T) {
+	t.Run("Decode plain string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"hello world"`))
+		result, err := DecodeBytesRaw(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal([]byte("hello world"))
+	})
+
+	t.Run("Decode base64-looking string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"aGVsbG8="`))
+		result, err := DecodeBytesRaw(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal([]byte("aGVsbG8="))
+	})
+
+	t.Run("Decode escaped string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"a\tb\u00e9\""`))
+		result, err := DecodeBytesRaw(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal([]byte("a\tb\u00e9\""))
+	})
+
+	t.Run("Decode empty string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`""`))
+		result, err := DecodeBytesRaw(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal([]byte{})
+	})
+
+	t.Run("Decode invalid type", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`123`))
+		_, err := DecodeBytesRaw(d)
+		assert.Error(t, err).String("invalid JSON: expected string but got `123`")
+	})
+}
+
 func TestDecodeBytes(t *testing.T) {
 	t.Run("Decode base64 bytes", func(t *testing.T) {
 		originalBytes := []byte("hello world")
@@ -561,6 +882,99 @@ func TestDecodeBytes(t *testing.T) {
 	})
 }
 
+func TestDecodeTimeKey(t *testing.T) {
+	t.Run("Decode RFC 3339 keys", func(t *testing.T) {
+		s := `{"2023-11-14T22:13:20Z": 1.5, "2023-11-15T06:13:20+08:00": 2.5, "2023-11-14T22:13:21Z": 3}`
+		d := NewDecoder(strings.NewReader(s))
+		result, err := DecodeMap(DecodeTimeKey, DecodeFloat[float64])(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[time.Time]float64{
+			time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC): 2.5,
+			time.Date(2023, 11, 14, 22, 13, 21, 0, time.UTC): 3,
+		})
+	})
+
+	t.Run("Decode Unix second keys", func(t *testing.T) {
+		s := `{"1700000000": 1.5, "1700000060": -2, "0": 0}`
+		d := NewDecoder(strings.NewReader(s))
+		result, err := DecodeMap(DecodeUnixTimeKey, DecodeFloat[float64])(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[time.Time]float64{
+			time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC): 1.5,
+			time.Date(2023, 11, 14, 22, 14, 20, 0, time.UTC): -2,
+			time.Unix(0, 0).UTC():                            0,
+		})
+	})
+
+	t.Run("Decode invalid RFC 3339 key", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"1700000000": 1}`))
+		_, err := DecodeMap(DecodeTimeKey, DecodeFloat[float64])(d)
+		assert.Error(t, err).Contains("invalid JSON: expected RFC 3339 time key but got `1700000000`")
+	})
+
+	t.Run("Decode invalid Unix second key", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"2023-11-14": 1}`))
+		_, err := DecodeMap(DecodeUnixTimeKey, DecodeFloat[float64])(d)
+		assert.Error(t, err).Contains("invalid syntax")
+	})
+}
+
+func TestDecodeTimeUnix(t *testing.T) {
+	t.Run("Decode seconds", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("1700000000"))
+		result, err := DecodeTimeUnix(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+	})
+
+	t.Run("Decode milliseconds", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("1700000000123"))
+		result, err := DecodeTimeUnixMilli(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC))
+	})
+
+	t.Run("Decode seconds pointer", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("0"))
+		result, err := DecodeTimeUnixPtr(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).NotNil()
+		assert.That(t, *result).Equal(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC))
+	})
+
+	t.Run("Decode null seconds pointer", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		result, err := DecodeTimeUnixPtr(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Nil()
+	})
+
+	t.Run("Decode null milliseconds pointer", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		result, err := DecodeTimeUnixMilliPtr(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Nil()
+	})
+
+	t.Run("Decode null", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		_, err := DecodeTimeUnix(d)
+		assert.Error(t, err).String("invalid JSON: expected number but got `null`")
+	})
+
+	t.Run("Decode string", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`"1700000000"`))
+		_, err := DecodeTimeUnixMilli(d)
+		assert.Error(t, err).String("invalid JSON: expected number but got `1700000000`")
+	})
+
+	t.Run("Decode fractional seconds", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("1700000000.5"))
+		_, err := DecodeTimeUnix(d)
+		assert.Error(t, err).String("strconv.ParseInt: parsing \"1700000000.5\": invalid syntax")
+	})
+}
+
 func TestDecodeArray(t *testing.T) {
 	t.Run("Decode int array", func(t *testing.T) {
 		d := NewDecoder(strings.NewReader("[1, 2, 3, 4, 5]"))
@@ -641,17 +1055,162 @@ func TestDecodeArray(t *testing.T) {
 	t.Run("Decode int array with invalid element", func(t *testing.T) {
 		d := NewDecoder(strings.NewReader("[1, 2, \"invalid\", 4]"))
 		_, err := DecodeArray(DecodeInt[int])(d)
-		assert.Error(t, err).String("invalid JSON: expected number but got `invalid`")
+		assert.Error(t, err).String("[2] >> invalid JSON: expected number but got `invalid`")
 	})
 
 	t.Run("Decode uint array with invalid element", func(t *testing.T) {
 		d := NewDecoder(strings.NewReader("[1, 2, \"invalid\", 4]"))
 		_, err := DecodeArray(DecodeUint[uint])(d)
-		assert.Error(t, err).String("invalid JSON: expected number but got `invalid`")
+		assert.Error(t, err).String("[2] >> invalid JSON: expected number but got `invalid`")
+	})
+
+	t.Run("Decode unterminated array", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("[1, 2"))
+		_, err := DecodeArray(DecodeInt[int])(d)
+		assert.Error(t, err).Matches("^while decoding array element 2: jsontext: unexpected EOF")
+	})
+
+	t.Run("Decode array with trailing comma", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("[1, 2,]"))
+		_, err := DecodeArray(DecodeInt[int])(d)
+		assert.Error(t, err).Matches("^while decoding array element 2: jsontext: invalid character ','")
+	})
+
+	t.Run("Decode array with missing comma", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("[[1], [2 3]]"))
+		_, err := DecodeArray(DecodeArray(DecodeInt[int]))(d)
+		assert.Error(t, err).Matches("^\\[1\\] >> while decoding array element 1: jsontext: invalid character '3'")
+	})
+
+	t.Run("Decode nested array with invalid element", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("[[1], [2, null]]"))
+		_, err := DecodeArray(DecodeArray(DecodeInt[int]))(d)
+		assert.Error(t, err).String("[1] >> [1] >> invalid JSON: expected number but got `null`")
+	})
+}
+
+func TestDecodeNumberArray(t *testing.T) {
+	for _, input := range []string{
+		"null",
+		"[]",
+		"[1, -2, 3]",
+		"[9223372036854775807]",
+		"[9223372036854775808]",
+		"[1.5]",
+		"[1, null]",
+		`[1, "2"]`,
+		"[1, [2]]",
+		"[1, 2,]",
+		"[1, 2",
+		"{}",
+		"",
+	} {
+		d := NewDecoder(strings.NewReader(input))
+		expect, expectErr := DecodeArray(DecodeInt[int64])(d)
+		d = NewDecoder(strings.NewReader(input))
+		result, err := DecodeIntArray[int64](d)
+		assert.That(t, result).Equal(expect, input)
+		assert.That(t, fmt.Sprint(err)).Equal(fmt.Sprint(expectErr), input)
+	}
+
+	for _, input := range []string{
+		"null",
+		"[]",
+		"[1, -2.5, 3e2]",
+		"[1e39]",
+		"[1, null]",
+		"[true]",
+		"[1,, 2]",
+		`"1"`,
+	} {
+		d := NewDecoder(strings.NewReader(input))
+		expect, expectErr := DecodeArray(DecodeFloat[float32])(d)
+		d = NewDecoder(strings.NewReader(input))
+		result, err := DecodeFloatArray[float32](d)
+		assert.That(t, result).Equal(expect, input)
+		assert.That(t, fmt.Sprint(err)).Equal(fmt.Sprint(expectErr), input)
+	}
+
+	t.Run("Decode int8 overflow", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("[1, 128]"))
+		_, err := DecodeIntArray[int8](d)
+		assert.Error(t, err).String("[1] >> invalid JSON: number out of range, got `128")
+	})
+
+	t.Run("Decode as element decoder", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": [1.5, 2], "b": null}`))
+		result, err := DecodeMap(DecodeStringKey, DecodeFloatArray[float64])(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[string][]float64{"a": {1.5, 2}, "b": nil})
+	})
+}
+
+func BenchmarkDecodeNumberArray(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := range 1000 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.Itoa(i * 7919))
+	}
+	sb.WriteString("]")
+	s := sb.String()
+
+	b.Run("DecodeArray(DecodeInt)", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d := NewDecoder(strings.NewReader(s))
+			if _, err := DecodeArray(DecodeInt[int])(d); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("DecodeIntArray", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d := NewDecoder(strings.NewReader(s))
+			if _, err := DecodeIntArray[int](d); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("DecodeArray(DecodeFloat)", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d := NewDecoder(strings.NewReader(s))
+			if _, err := DecodeArray(DecodeFloat[float64])(d); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("DecodeFloatArray", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d := NewDecoder(strings.NewReader(s))
+			if _, err := DecodeFloatArray[float64](d); err != nil {
+				b.Fatal(err)
+			}
+		}
 	})
 }
 
 func TestDecodeMap(t *testing.T) {
+	t.Run("Decode unterminated map", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": 1, "b": 2`))
+		_, err := DecodeMap(DecodeString, DecodeInt[int])(d)
+		assert.Error(t, err).Matches("^while decoding map entry 2: jsontext: unexpected EOF")
+	})
+
+	t.Run("Decode map with trailing comma", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": 1,}`))
+		_, err := DecodeMap(DecodeString, DecodeInt[int])(d)
+		assert.Error(t, err).Matches("^while decoding map entry 1: jsontext: invalid character ','")
+	})
+
 	t.Run("Decode string-int map", func(t *testing.T) {
 		d := NewDecoder(strings.NewReader(`{"a": 1, "b": 2, "c": 3}`))
 		result, err := DecodeMap(DecodeString, DecodeInt[int])(d)
@@ -751,6 +1310,42 @@ func TestDecodeMap(t *testing.T) {
 	})
 }
 
+func TestDecodeMultiMap(t *testing.T) {
+	t.Run("Decode string-int multimap", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": [1, 2], "b": [3]}`))
+		result, err := DecodeMultiMap(DecodeStringKey, DecodeInt[int])(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[string][]int{"a": {1, 2}, "b": {3}})
+	})
+
+	t.Run("Decode empty and null values", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": [], "b": null}`))
+		result, err := DecodeMultiMap(DecodeStringKey, DecodeInt[int])(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[string][]int{"a": {}, "b": nil})
+	})
+
+	t.Run("Decode empty multimap", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("{}"))
+		result, err := DecodeMultiMap(DecodeStringKey, DecodeInt[int])(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[string][]int{})
+	})
+
+	t.Run("Decode null multimap", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		result, err := DecodeMultiMap(DecodeStringKey, DecodeInt[int])(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Nil()
+	})
+
+	t.Run("Decode non-array value", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": 1}`))
+		_, err := DecodeMultiMap(DecodeStringKey, DecodeInt[int])(d)
+		assert.Error(t, err).String("invalid JSON: expected `[` but got `1`")
+	})
+}
+
 func TestDecodeObjectBegin(t *testing.T) {
 	t.Run("Decode object begin with read token error", func(t *testing.T) {
 		d := NewDecoder(strings.NewReader(""))
@@ -795,11 +1390,43 @@ func TestDecodeAny(t *testing.T) {
 	t.Run("Decode any with unmarshal error", func(t *testing.T) {
 		d := NewDecoder(strings.NewReader("1e520"))
 		_, err := DecodeAny(d)
-		assert.Error(t, err).String("json: cannot unmarshal JSON number 1e520 into Go float64: value out of range")
+		assert.Error(t, err).Contains("unmarshal JSON number 1e520 into Go float64: value out of range")
 	})
 }
 
-type TestObject struct {
+func TestDecodeAnyTyped(t *testing.T) {
+	t.Run("Decode large integer as json.Number", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"id": 12345678901234567890, "list": [1, 2.5]}`))
+		result, err := DecodeAnyTyped(d, true)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[string]any{
+			"id":   stdjson.Number("12345678901234567890"),
+			"list": []any{stdjson.Number("1"), stdjson.Number("2.5")},
+		})
+	})
+
+	t.Run("Decode large integer as float64", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"id": 12345678901234567890}`))
+		result, err := DecodeAnyTyped(d, false)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(map[string]any{"id": float64(12345678901234567890)})
+	})
+
+	t.Run("Decode null", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		result, err := DecodeAnyTyped(d, true)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Nil()
+	})
+
+	t.Run("Decode with read token error", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(""))
+		_, err := DecodeAnyTyped(d, true)
+		assert.Error(t, err).String("EOF")
+	})
+}
+
+type TestObject struct {
 
 	// Base
 	Int    int
@@ -858,15 +1485,13 @@ func (b *TestObject) DecodeJSON(d Decoder) error {
 	b.Int = 9
 
 	// 记录必传字段
-	var (
-		foundInt bool
-	)
+	required := NewRequiredFields("Int")
 
 	for {
 		if d.PeekKind() == '}' {
 			break
 		}
-		key, err := DecodeString(d)
+		key, err := DecodeStringKey(d)
 		if err != nil {
 			return err
 		}
@@ -875,7 +1500,7 @@ func (b *TestObject) DecodeJSON(d Decoder) error {
 			if b.Int, err = DecodeInt[int](d); err != nil {
 				return err
 			}
-			foundInt = true
+			required.Found("Int")
 		case hashIntPtr:
 			if b.IntPtr, err = DecodeIntPtr[int](d); err != nil {
 				return err
@@ -901,7 +1526,7 @@ func (b *TestObject) DecodeJSON(d Decoder) error {
 				return err
 			}
 		case hashObjectList:
-			if b.ObjectList, err = DecodeArray(DecodeObject(NewTestObject))(d); err != nil {
+			if b.ObjectList, err = DecodeObjectArray(NewTestObject)(d); err != nil {
 				return err
 			}
 		case hashAnyList:
@@ -948,10 +1573,7 @@ func (b *TestObject) DecodeJSON(d Decoder) error {
 	}
 
 	// 检查必传字段
-	if !foundInt {
-		return errutil.Explain(nil, "missing required field Int")
-	}
-	return nil
+	return required.Check()
 }
 
 func TestDecodeObject(t *testing.T) {
@@ -1131,3 +1753,587 @@ func TestDecodeObject(t *testing.T) {
 		assert.That(t, *o.Object.Object.IntPtr).Equal(300)
 	})
 }
+
+type UnknownObject struct {
+	Name    string
+	Unknown map[string]RawMessage
+}
+
+func (o *UnknownObject) DecodeJSON(d Decoder) error {
+	if err := DecodeObjectBegin(d); err != nil {
+		return err
+	}
+	for d.PeekKind() != '}' {
+		key, err := DecodeStringKey(d)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "Name":
+			if o.Name, err = DecodeString(d); err != nil {
+				return err
+			}
+		default:
+			if o.Unknown == nil {
+				o.Unknown = make(map[string]RawMessage)
+			}
+			if o.Unknown[key], err = CaptureUnknown(d, key); err != nil {
+				return err
+			}
+		}
+	}
+	return DecodeObjectEnd(d)
+}
+
+func TestCaptureUnknown(t *testing.T) {
+
+	t.Run("Capture extra fields", func(t *testing.T) {
+		s := `{"Name":"x","Extra":{"a": [1, 2.50, "s"]},"Flag":true,"Nil":null}`
+		o := &UnknownObject{}
+		err := o.DecodeJSON(NewDecoder(strings.NewReader(s)))
+		assert.That(t, err).Nil()
+		assert.String(t, o.Name).Equal("x")
+		assert.That(t, o.Unknown).Equal(map[string]RawMessage{
+			"Extra": RawMessage(`{"a": [1, 2.50, "s"]}`),
+			"Flag":  RawMessage(`true`),
+			"Nil":   RawMessage(`null`),
+		})
+
+		b, err := Marshal(o.Unknown)
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal(`{"Extra":{"a":[1,2.50,"s"]},"Flag":true,"Nil":null}`)
+	})
+
+	t.Run("No extra fields", func(t *testing.T) {
+		o := &UnknownObject{}
+		err := o.DecodeJSON(NewDecoder(strings.NewReader(`{"Name":"x"}`)))
+		assert.That(t, err).Nil()
+		assert.That(t, o.Unknown).Nil()
+	})
+
+	t.Run("Invalid unknown value", func(t *testing.T) {
+		o := &UnknownObject{}
+		err := o.DecodeJSON(NewDecoder(strings.NewReader(`{"Extra":[1,}`)))
+		assert.Error(t, err).Matches("unknown field Extra >> .*invalid character")
+	})
+}
+
+func TestRequiredFields(t *testing.T) {
+
+	t.Run("All found", func(t *testing.T) {
+		r := NewRequiredFields("A", "B")
+		r.Found("B")
+		r.Found("A")
+		assert.That(t, r.Check()).Nil()
+	})
+
+	t.Run("Missing field", func(t *testing.T) {
+		r := NewRequiredFields("A", "B", "C")
+		r.Found("B")
+		r.Found("X")
+		assert.Error(t, r.Check()).String("missing required field A")
+	})
+
+	t.Run("No required fields", func(t *testing.T) {
+		assert.That(t, NewRequiredFields().Check()).Nil()
+	})
+
+	t.Run("Missing required field in object", func(t *testing.T) {
+		o := &TestObject{}
+		err := o.DecodeJSON(NewDecoder(strings.NewReader(`{"IntPtr": 1}`)))
+		assert.Error(t, err).String("missing required field Int")
+		assert.Number(t, o.Int).Equal(9)
+		assert.Number(t, *o.IntPtr).Equal(1)
+	})
+
+	t.Run("Default kept for optional field", func(t *testing.T) {
+		o := &DefaultObject{}
+		err := o.DecodeJSON(NewDecoder(strings.NewReader(`{"Name": "x"}`)))
+		assert.That(t, err).Nil()
+		assert.String(t, o.Name).Equal("x")
+		assert.Number(t, o.Port).Equal(8080)
+	})
+}
+
+type DefaultObject struct {
+	Name string
+	Port int
+}
+
+func (o *DefaultObject) DecodeJSON(d Decoder) error {
+	if err := DecodeObjectBegin(d); err != nil {
+		return err
+	}
+	o.Port = 8080
+	required := NewRequiredFields("Name")
+	for d.PeekKind() != '}' {
+		key, err := DecodeStringKey(d)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "Name":
+			if o.Name, err = DecodeString(d); err != nil {
+				return err
+			}
+			required.Found("Name")
+		case "Port":
+			if o.Port, err = DecodeInt[int](d); err != nil {
+				return err
+			}
+		default:
+			if err = d.SkipValue(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := DecodeObjectEnd(d); err != nil {
+		return err
+	}
+	return required.Check()
+}
+
+func TestDecodeSetBy(t *testing.T) {
+
+	t.Run("Dedup ints", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`[3, 1, 3, 2, 1]`))
+		result, err := DecodeSet(DecodeInt[int])(d)
+		assert.That(t, err).Nil()
+		assert.Slice(t, result).Equal([]int{3, 1, 2})
+	})
+
+	t.Run("Dedup objects by ID", func(t *testing.T) {
+		s := `[{"Int": 1, "IntPtr": 10}, {"Int": 2}, {"Int": 1, "IntPtr": 20}, null]`
+		d := NewDecoder(strings.NewReader(s))
+		result, err := DecodeSetBy(DecodeObject(NewTestObject), func(o *TestObject) int {
+			if o == nil {
+				return -1
+			}
+			return o.Int
+		})(d)
+		assert.That(t, err).Nil()
+		assert.Number(t, len(result)).Equal(3)
+		assert.Number(t, result[0].Int).Equal(1)
+		assert.Number(t, *result[0].IntPtr).Equal(10)
+		assert.Number(t, result[1].Int).Equal(2)
+		assert.That(t, result[2]).Nil()
+	})
+
+	t.Run("Decode null", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`null`))
+		result, err := DecodeSetBy(DecodeString, strings.ToLower)(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Nil()
+	})
+
+	t.Run("Decode invalid element", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`["a", 1]`))
+		_, err := DecodeSetBy(DecodeString, strings.ToLower)(d)
+		assert.Error(t, err).String("[1] >> invalid JSON: expected string but got `1`")
+	})
+}
+
+type PatchObject struct {
+	Object    *TestObject
+	ObjectSet bool // Object occurs in the input, possibly as null
+	Present   bool // Object occurs in the input and is not null
+}
+
+func (o *PatchObject) DecodeJSON(d Decoder) error {
+	if err := DecodeObjectBegin(d); err != nil {
+		return err
+	}
+	for d.PeekKind() != '}' {
+		key, err := DecodeStringKey(d)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "Object":
+			if o.Object, o.Present, err = DecodeObjectOpt(NewTestObject)(d); err != nil {
+				return err
+			}
+			o.ObjectSet = true
+		default:
+			if err = d.SkipValue(); err != nil {
+				return err
+			}
+		}
+	}
+	return DecodeObjectEnd(d)
+}
+
+func TestDecodeObjectOpt(t *testing.T) {
+
+	t.Run("Decode null", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`null`))
+		v, present, err := DecodeObjectOpt(NewTestObject)(d)
+		assert.That(t, err).Nil()
+		assert.That(t, v).Nil()
+		assert.That(t, present).False()
+	})
+
+	t.Run("Decode object", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"Int": 1}`))
+		v, present, err := DecodeObjectOpt(NewTestObject)(d)
+		assert.That(t, err).Nil()
+		assert.Number(t, v.Int).Equal(1)
+		assert.That(t, present).True()
+	})
+
+	t.Run("Decode invalid value", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`123`))
+		_, present, err := DecodeObjectOpt(NewTestObject)(d)
+		assert.Error(t, err).String("invalid JSON: expected `{` but got `123`")
+		assert.That(t, present).False()
+	})
+
+	t.Run("Null versus missing field", func(t *testing.T) {
+		for _, c := range []struct {
+			s       string
+			set     bool
+			present bool
+		}{
+			{`{}`, false, false},
+			{`{"Object": null}`, true, false},
+			{`{"Object": {"Int": 2}}`, true, true},
+		} {
+			o := &PatchObject{}
+			err := o.DecodeJSON(NewDecoder(strings.NewReader(c.s)))
+			assert.That(t, err).Nil(c.s)
+			assert.That(t, o.ObjectSet).Equal(c.set, c.s)
+			assert.That(t, o.Present).Equal(c.present, c.s)
+		}
+	})
+}
+
+type Circle struct {
+	Radius float64
+}
+
+func (c *Circle) DecodeJSON(d Decoder) error {
+	if err := DecodeObjectBegin(d); err != nil {
+		return err
+	}
+	for d.PeekKind() != '}' {
+		key, err := DecodeStringKey(d)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "radius":
+			if c.Radius, err = DecodeFloat[float64](d); err != nil {
+				return err
+			}
+		default:
+			if err = d.SkipValue(); err != nil {
+				return err
+			}
+		}
+	}
+	return DecodeObjectEnd(d)
+}
+
+type Label struct {
+	Text string
+}
+
+func (l *Label) DecodeJSON(d Decoder) error {
+	if err := DecodeObjectBegin(d); err != nil {
+		return err
+	}
+	for d.PeekKind() != '}' {
+		key, err := DecodeStringKey(d)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "text":
+			if l.Text, err = DecodeString(d); err != nil {
+				return err
+			}
+		default:
+			if err = d.SkipValue(); err != nil {
+				return err
+			}
+		}
+	}
+	return DecodeObjectEnd(d)
+}
+
+type Group struct {
+	Items []any
+}
+
+func (g *Group) DecodeJSON(d Decoder) error {
+	if err := DecodeObjectBegin(d); err != nil {
+		return err
+	}
+	for d.PeekKind() != '}' {
+		key, err := DecodeStringKey(d)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "items":
+			if g.Items, err = DecodeArray(DecodeRegistered("type"))(d); err != nil {
+				return err
+			}
+		default:
+			if err = d.SkipValue(); err != nil {
+				return err
+			}
+		}
+	}
+	return DecodeObjectEnd(d)
+}
+
+// registerType registers newFn under name for the duration of the test.
+func registerType(t *testing.T, name string, newFn func() Object) {
+	RegisterType(name, newFn)
+	t.Cleanup(func() {
+		typeRegistry.Lock()
+		defer typeRegistry.Unlock()
+		delete(typeRegistry.m, name)
+	})
+}
+
+func TestDecodeObjectArray(t *testing.T) {
+	t.Run("Decode with null element", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`[{"Int": 1}, null, {"Int": 3}]`))
+		result, err := DecodeObjectArray(NewTestObject)(d)
+		assert.That(t, err).Nil()
+		assert.That(t, len(result)).Equal(3)
+		assert.That(t, result[0].Int).Equal(1)
+		assert.That(t, result[1]).Nil()
+		assert.That(t, result[2].Int).Equal(3)
+	})
+
+	t.Run("Decode empty array", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("[]"))
+		result, err := DecodeObjectArray(NewTestObject)(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal([]*TestObject{})
+	})
+
+	t.Run("Decode null array", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("null"))
+		result, err := DecodeObjectArray(NewTestObject)(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Nil()
+	})
+
+	t.Run("Decode invalid element", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`[{"Int": 1}, 2]`))
+		_, err := DecodeObjectArray(NewTestObject)(d)
+		assert.Error(t, err).String("[1] >> invalid JSON: expected `{` but got `2`")
+	})
+}
+
+func TestDecodeRegistered(t *testing.T) {
+	registerType(t, "circle", func() Object { return &Circle{} })
+	registerType(t, "label", func() Object { return &Label{} })
+	registerType(t, "group", func() Object { return &Group{} })
+
+	t.Run("Decode mixed array", func(t *testing.T) {
+		s := `[{"type": "circle", "radius": 1.5}, {"text": "hi", "type": "label"}, null]`
+		result, err := DecodeArray(DecodeRegistered("type"))(NewDecoder(strings.NewReader(s)))
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal([]any{&Circle{Radius: 1.5}, &Label{Text: "hi"}, nil})
+	})
+
+	t.Run("Decode unregistered type", func(t *testing.T) {
+		s := `[{"type": "circle"}, {"type": "square", "side": 2}]`
+		_, err := DecodeArray(DecodeRegistered("type"))(NewDecoder(strings.NewReader(s)))
+		assert.Error(t, err).String("[1] >> invalid JSON: unregistered type `square`")
+	})
+
+	t.Run("Decode missing type field", func(t *testing.T) {
+		_, err := DecodeRegistered("type")(NewDecoder(strings.NewReader(`{"radius": 1}`)))
+		assert.Error(t, err).String("invalid JSON: missing type field type")
+	})
+
+	t.Run("Decode non-string type field", func(t *testing.T) {
+		_, err := DecodeRegistered("type")(NewDecoder(strings.NewReader(`{"type": 1}`)))
+		assert.Error(t, err).String("invalid JSON: expected string but got `1`")
+	})
+
+	t.Run("Decode nested registered types", func(t *testing.T) {
+		s := `{"type": "group", "items": [{"type": "group", "items": [{"type": "label", "text": "hi"}]}]}`
+		result, err := DecodeRegistered("type")(NewDecoder(strings.NewReader(s)))
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(&Group{Items: []any{&Group{Items: []any{&Label{Text: "hi"}}}}})
+	})
+
+	t.Run("Max depth applies across registered types", func(t *testing.T) {
+		s := `{"type": "group", "items": [{"type": "group", "items": [{"type": "label"}]}]}`
+		_, err := DecodeRegistered("type")(NewDecoder(strings.NewReader(s), MaxDepth(4)))
+		assert.Error(t, err).String("[0] >> [0] >> invalid JSON: exceeded max depth 4")
+
+		_, err = DecodeRegistered("type")(NewDecoder(strings.NewReader(s), MaxDepth(5)))
+		assert.That(t, err).Nil()
+	})
+
+	t.Run("Decode non-object", func(t *testing.T) {
+		_, err := DecodeRegistered("type")(NewDecoder(strings.NewReader(`"circle"`)))
+		assert.Error(t, err).String("invalid JSON: expected `{` but got `circle`")
+	})
+}
+
+func TestDecodeMapFiltered(t *testing.T) {
+
+	t.Run("Keep selected keys", func(t *testing.T) {
+		var sb strings.Builder
+		sb.WriteString("{")
+		for i := range 20 {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			switch i {
+			case 3, 7, 15:
+				fmt.Fprintf(&sb, `"k%d": %d`, i, i)
+			default:
+				fmt.Fprintf(&sb, `"k%d": {"not": ["a", "number", %d]}`, i, i)
+			}
+		}
+		sb.WriteString("}")
+
+		want := map[string]bool{"k3": true, "k7": true, "k15": true, "missing": true}
+		d := NewDecoder(strings.NewReader(sb.String()))
+		result, err := DecodeMapFiltered(DecodeString, DecodeInt[int], want)(d)
+		assert.That(t, err).Nil()
+		assert.Map(t, result).Equal(map[string]int{"k3": 3, "k7": 7, "k15": 15})
+	})
+
+	t.Run("Invalid wanted value", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": 1, "b": "x"}`))
+		_, err := DecodeMapFiltered(DecodeString, DecodeInt[int], map[string]bool{"b": true})(d)
+		assert.Error(t, err).String("invalid JSON: expected number but got `x`")
+	})
+
+	t.Run("Malformed skipped value", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`{"a": [1,}`))
+		_, err := DecodeMapFiltered(DecodeString, DecodeInt[int], map[string]bool{"b": true})(d)
+		assert.Error(t, err).Contains("invalid character")
+	})
+
+	t.Run("Decode null", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`null`))
+		result, err := DecodeMapFiltered(DecodeString, DecodeInt[int], nil)(d)
+		assert.That(t, err).Nil()
+		assert.That(t, result).Nil()
+	})
+}
+
+func TestGetByPath(t *testing.T) {
+	raw := RawMessage(`{
+		"a": {"b": [10, {"x": 1}, {"c": "found", "d": [true, null]}]},
+		"n": 1.5,
+		"s": "str",
+		"k.with.dots": 1
+	}`)
+
+	for _, c := range []struct {
+		path   string
+		expect string
+	}{
+		{"n", `1.5`},
+		{"s", `"str"`},
+		{"a.b[0]", `10`},
+		{"a.b[2].c", `"found"`},
+		{"a.b[2].d[1]", `null`},
+		{"a.b[1]", `{"x": 1}`},
+		{"a.b[2].d", `[true, null]`},
+	} {
+		v, err := GetByPath(raw, c.path)
+		assert.That(t, err).Nil(c.path)
+		assert.String(t, string(v)).Equal(c.expect, c.path)
+	}
+
+	t.Run("Empty path", func(t *testing.T) {
+		v, err := GetByPath(RawMessage(` [1, 2] `), "")
+		assert.That(t, err).Nil()
+		assert.String(t, string(v)).Equal(`[1, 2]`)
+	})
+
+	t.Run("Key not found", func(t *testing.T) {
+		_, err := GetByPath(raw, "a.b[2].missing")
+		assert.Error(t, err).String("path `a.b[2]`: key `missing` not found")
+	})
+
+	t.Run("Index out of range", func(t *testing.T) {
+		_, err := GetByPath(raw, "a.b[3]")
+		assert.Error(t, err).String("path `a.b`: index 3 out of range")
+	})
+
+	t.Run("Type mismatch", func(t *testing.T) {
+		_, err := GetByPath(raw, "a.b.c")
+		assert.Error(t, err).String("path `a.b`: expected object but got `[`")
+
+		_, err = GetByPath(raw, "s[0]")
+		assert.Error(t, err).String("path `s`: expected array but got `str`")
+	})
+
+	t.Run("Invalid document after subtree", func(t *testing.T) {
+		for _, s := range []string{`{"a": 1} garbage`, `{"a": 1, "b": }`, `{"a": [1, 2}`, `{"a": 1`, `{"a": 1}}`} {
+			_, err := GetByPath(RawMessage(s), "a")
+			assert.That(t, err).NotNil(s)
+		}
+
+		_, err := GetByPath(RawMessage(`{"a": 1} garbage`), "a")
+		assert.Error(t, err).Contains("invalid character 'g'")
+
+		_, err = GetByPath(RawMessage(`[1] [2]`), "")
+		assert.Error(t, err).String("invalid JSON: unexpected `[` after top-level value")
+	})
+
+	t.Run("Invalid path", func(t *testing.T) {
+		for _, path := range []string{".a", "a..b", "a.", "a[", "a[x]", "a[-1]", "a[0]b"} {
+			_, err := GetByPath(raw, path)
+			assert.Error(t, err).String("invalid path `"+path+"`", path)
+		}
+	})
+}
+
+func TestMaxDepth(t *testing.T) {
+	nested := func(n int) string {
+		return strings.Repeat(`{"Int": 1, "Object": `, n-1) + `{"Int": 1}` + strings.Repeat("}", n-1)
+	}
+
+	t.Run("Self-referential object within limit", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(nested(defaultMaxDepth)))
+		v, err := DecodeObject(NewTestObject)(d)
+		assert.That(t, err).Nil()
+		depth := 0
+		for ; v != nil; v = v.Object {
+			depth++
+		}
+		assert.That(t, depth).Equal(defaultMaxDepth)
+	})
+
+	t.Run("Self-referential object too deep", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(nested(5000)))
+		_, err := DecodeObject(NewTestObject)(d)
+		assert.Error(t, err).String("invalid JSON: exceeded max depth 1000")
+	})
+
+	t.Run("Arrays and maps", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader("[[[1]]]"), MaxDepth(3))
+		_, err := DecodeArray(DecodeArray(DecodeIntArray[int]))(d)
+		assert.That(t, err).Nil()
+
+		d = NewDecoder(strings.NewReader("[[[[1]]]]"), MaxDepth(3))
+		_, err = DecodeArray(DecodeArray(DecodeArray(DecodeIntArray[int])))(d)
+		assert.Error(t, err).String("[0] >> [0] >> [0] >> invalid JSON: exceeded max depth 3")
+
+		d = NewDecoder(strings.NewReader(`{"a": {"b": [{"c": 1}]}}`), MaxDepth(3))
+		_, err = DecodeMap(DecodeStringKey, DecodeMap(DecodeStringKey, DecodeArray(DecodeMap(DecodeStringKey, DecodeInt[int]))))(d)
+		assert.Error(t, err).Contains("invalid JSON: exceeded max depth 3")
+	})
+
+	t.Run("Limit disabled", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(nested(2*defaultMaxDepth)), MaxDepth(0))
+		_, err := DecodeObject(NewTestObject)(d)
+		assert.That(t, err).Nil()
+	})
+}
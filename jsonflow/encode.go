@@ -17,6 +17,13 @@
 package jsonflow
 
 import (
+	"bytes"
+	"encoding/base64"
+	"iter"
+	"reflect"
+	"slices"
+	"strconv"
+
 	"github.com/lvan100/golib/jsonflow/internal/json"
 )
 
@@ -35,18 +42,317 @@ type (
 	NilSliceAsNull bool
 	NilMapAsNull   bool
 	Deterministic  bool
+
+	// ByteSliceAsArray encodes []byte values as JSON arrays of numbers
+	// instead of base64 strings. It applies to Marshal and MarshalWrite,
+	// and, through an Encoder created by NewEncoder, to EncodeBytes and
+	// EncodeAny. Marshal and EncodeAny apply it to the unnamed []byte
+	// type only.
+	ByteSliceAsArray bool
 )
 
-func (Indent) JSONOptions(NotForPublicUse)         {}
-func (IndentPrefix) JSONOptions(NotForPublicUse)   {}
-func (NilSliceAsNull) JSONOptions(NotForPublicUse) {}
-func (NilMapAsNull) JSONOptions(NotForPublicUse)   {}
-func (Deterministic) JSONOptions(NotForPublicUse)  {}
+// MapKeyOrder compares the encoded keys of a map and is consulted when
+// Deterministic is enabled, e.g. to sort numeric keys numerically instead
+// of lexically. It only affects maps written by EncodeMap through an
+// Encoder created with this option; Marshal keeps its lexical ordering.
+type MapKeyOrder func(a, b string) int
+
+func (Indent) JSONOptions(NotForPublicUse)           {}
+func (IndentPrefix) JSONOptions(NotForPublicUse)     {}
+func (NilSliceAsNull) JSONOptions(NotForPublicUse)   {}
+func (NilMapAsNull) JSONOptions(NotForPublicUse)     {}
+func (Deterministic) JSONOptions(NotForPublicUse)    {}
+func (ByteSliceAsArray) JSONOptions(NotForPublicUse) {}
+func (MapKeyOrder) JSONOptions(NotForPublicUse)      {}
 
 // Encoder is a streaming JSON encoder.
 type Encoder = json.Encoder
 
+// Encodable is implemented by types that can write themselves to a JSON Encoder.
+type Encodable interface {
+	// EncodeJSON writes the object as JSON to the Encoder.
+	EncodeJSON(e Encoder) error
+}
+
+// MarshalObject encodes v by calling its EncodeJSON method and returns the
+// resulting bytes. It is the streaming counterpart of Marshal: the options
+// apply through the Encoder passed to EncodeJSON, as described for NewEncoder,
+// except that MapKeyOrder only affects EncodeMap. A nil v is encoded as null.
+func MarshalObject[T Encodable](v T, opts ...MarshalOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := EncodeObject(NewEncoder(&buf, opts...), v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// formatter is implemented by encoders that carry formatting options,
+// such as those created by NewEncoder.
+type formatter interface {
+	FormatNilSliceAsNull() bool
+	FormatNilMapAsNull() bool
+	FormatByteSliceAsArray() bool
+}
+
+// defaultFormatter applies the defaults of Marshal: nil slices and maps
+// are encoded as null and byte slices as base64 strings.
+type defaultFormatter struct{}
+
+func (defaultFormatter) FormatNilSliceAsNull() bool   { return true }
+func (defaultFormatter) FormatNilMapAsNull() bool     { return true }
+func (defaultFormatter) FormatByteSliceAsArray() bool { return false }
+
+// formatOf returns the formatting options of e, or the defaults of
+// Marshal if e does not carry any.
+func formatOf(e Encoder) formatter {
+	if f, ok := e.(formatter); ok {
+		return f
+	}
+	return defaultFormatter{}
+}
+
+// EncodeValuePtr encodes a pointer to a scalar value using encodeFn.
+// A nil pointer is encoded as null.
+func EncodeValuePtr[T any](
+	encodeFn func(e Encoder, v T) error,
+) func(e Encoder, v *T) error {
+	return func(e Encoder, v *T) error {
+		if v == nil {
+			return e.WriteToken("null", 'n')
+		}
+		return encodeFn(e, *v)
+	}
+}
+
 // EncodeInt encodes an integer value to JSON.
 func EncodeInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](e Encoder, i T) error {
+	return e.WriteToken(strconv.FormatInt(int64(i), 10), '0')
+}
+
+// EncodeIntPtr encodes a pointer to integer type T, or null if it is nil.
+func EncodeIntPtr[T ~int | ~int8 | ~int16 | ~int32 | ~int64](e Encoder, i *T) error {
+	return EncodeValuePtr(EncodeInt[T])(e, i)
+}
+
+// FormatIntKey formats an integer as a JSON object key.
+func FormatIntKey[T ~int | ~int8 | ~int16 | ~int32 | ~int64](i T) string {
+	return strconv.FormatInt(int64(i), 10)
+}
+
+// EncodeString encodes a string value to JSON.
+func EncodeString(e Encoder, s string) error {
+	return e.WriteToken(s, '"')
+}
+
+// EncodeStringPtr encodes a pointer to string, or null if it is nil.
+func EncodeStringPtr(e Encoder, s *string) error {
+	return EncodeValuePtr(EncodeString)(e, s)
+}
+
+// EncodeBytes encodes bytes as a base64 string, or as an array of numbers
+// if the encoder has ByteSliceAsArray(true). Nil bytes are encoded as null,
+// or as an empty string or array if the encoder has NilSliceAsNull(false).
+func EncodeBytes(e Encoder, b []byte) error {
+	f := formatOf(e)
+	if b == nil && f.FormatNilSliceAsNull() {
+		return e.WriteToken("null", 'n')
+	}
+	if !f.FormatByteSliceAsArray() {
+		return e.WriteToken(base64.StdEncoding.EncodeToString(b), '"')
+	}
+	if err := e.WriteToken("[", '['); err != nil {
+		return err
+	}
+	for _, c := range b {
+		if err := e.WriteToken(strconv.Itoa(int(c)), '0'); err != nil {
+			return err
+		}
+	}
+	return e.WriteToken("]", ']')
+}
+
+// FormatStringKey formats a string as a JSON object key.
+func FormatStringKey(s string) string {
+	return s
+}
+
+// valueMarshaler is implemented by encoders that can marshal a Go value
+// with their own options, such as those created by NewEncoder.
+type valueMarshaler interface {
+	MarshalValue(v any) error
+}
+
+// EncodeAny encodes an arbitrary Go value (scalar, object, or array)
+// by marshaling it and writing the result to the encoder. A nil value is
+// encoded as null. Encoders created by NewEncoder marshal v with their own
+// options, such as Deterministic, NilSliceAsNull and ByteSliceAsArray, but
+// MapKeyOrder only applies to EncodeMap. Other encoders use Marshal's defaults.
+func EncodeAny(e Encoder, v any) error {
+	if m, ok := e.(valueMarshaler); ok {
+		return m.MarshalValue(v)
+	}
+	b, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	return e.WriteValue(b)
+}
+
+// flusher is implemented by encoders that can flush their underlying
+// writer, such as those created by NewEncoder.
+type flusher interface {
+	Flush() error
+}
+
+// Flush flushes the writer underlying e, if both support flushing, so that
+// values written so far reach e.g. a bufio.Writer's destination or an HTTP
+// client mid-stream. Each completed top-level value has already been handed
+// to the writer; a top-level value still being written stays buffered until
+// complete. Flush does nothing for encoders that do not support it.
+func Flush(e Encoder) error {
+	if f, ok := e.(flusher); ok {
+		return f.Flush()
+	}
 	return nil
 }
+
+// EncodeObjectBegin writes the opening '{' token of a JSON object.
+func EncodeObjectBegin(e Encoder) error {
+	return e.WriteToken("{", '{')
+}
+
+// EncodeObjectEnd writes the closing '}' token of a JSON object.
+func EncodeObjectEnd(e Encoder) error {
+	return e.WriteToken("}", '}')
+}
+
+// EncodeKey writes the key of the next JSON object member.
+func EncodeKey(e Encoder, key string) error {
+	return e.WriteToken(key, '"')
+}
+
+// EncodeObject encodes an object that implements the Encodable interface
+// by calling its EncodeJSON method. A nil object is encoded as null.
+func EncodeObject[T Encodable](e Encoder, v T) error {
+	if isNil(v) {
+		return e.WriteToken("null", 'n')
+	}
+	return v.EncodeJSON(e)
+}
+
+// isNil reports whether v is a nil interface or a nil pointer.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// EncodeArray encodes a Go slice as a JSON array.
+// encodeFn is used to encode each element of the slice.
+// A nil slice is encoded as null, or as an empty array if the encoder
+// has NilSliceAsNull(false).
+func EncodeArray[T any](
+	encodeFn func(e Encoder, v T) error,
+) func(e Encoder, s []T) error {
+	return func(e Encoder, s []T) error {
+		if s == nil && formatOf(e).FormatNilSliceAsNull() {
+			return e.WriteToken("null", 'n')
+		}
+		if err := e.WriteToken("[", '['); err != nil {
+			return err
+		}
+		for _, v := range s {
+			if err := encodeFn(e, v); err != nil {
+				return err
+			}
+		}
+		return e.WriteToken("]", ']')
+	}
+}
+
+// EncodeArrayFunc encodes the elements produced by items as a JSON array,
+// using encFn to encode each one. The sequence is consumed lazily, so large
+// or generated collections can be written without building a slice first.
+// Encoding stops at the first error returned by encFn.
+func EncodeArrayFunc[T any](e Encoder, items iter.Seq[T], encFn func(e Encoder, v T) error) error {
+	if err := e.WriteToken("[", '['); err != nil {
+		return err
+	}
+	for v := range items {
+		if err := encFn(e, v); err != nil {
+			return err
+		}
+	}
+	return e.WriteToken("]", ']')
+}
+
+// keyOrderer is implemented by encoders that order the keys of maps,
+// such as those created by NewEncoder.
+type keyOrderer interface {
+	// MapKeyOrder returns the comparison function used to order the encoded
+	// keys of maps, or nil if map keys may be written in any order.
+	MapKeyOrder() func(a, b string) int
+}
+
+// mapKeyOrder returns the map key order of e, or nil if it has none.
+func mapKeyOrder(e Encoder) func(a, b string) int {
+	if o, ok := e.(keyOrderer); ok {
+		return o.MapKeyOrder()
+	}
+	return nil
+}
+
+// EncodeMap encodes a Go map as a JSON object.
+// formatKeyFn formats each key and encodeValFn encodes each value.
+// A nil map is encoded as null, or as an empty object if the encoder has
+// NilMapAsNull(false). Keys are written in the order given by the encoder's
+// MapKeyOrder, or in map iteration order if the encoder has none.
+func EncodeMap[K comparable, V any](
+	formatKeyFn func(k K) string,
+	encodeValFn func(e Encoder, v V) error,
+) func(e Encoder, m map[K]V) error {
+	return func(e Encoder, m map[K]V) error {
+		if m == nil && formatOf(e).FormatNilMapAsNull() {
+			return e.WriteToken("null", 'n')
+		}
+		type entry struct {
+			key string
+			val V
+		}
+		entries := make([]entry, 0, len(m))
+		for k, v := range m {
+			entries = append(entries, entry{key: formatKeyFn(k), val: v})
+		}
+		if keyOrder := mapKeyOrder(e); keyOrder != nil {
+			slices.SortFunc(entries, func(a, b entry) int {
+				return keyOrder(a.key, b.key)
+			})
+		}
+		if err := e.WriteToken("{", '{'); err != nil {
+			return err
+		}
+		for _, x := range entries {
+			if err := e.WriteToken(x.key, '"'); err != nil {
+				return err
+			}
+			if err := encodeValFn(e, x.val); err != nil {
+				return err
+			}
+		}
+		return e.WriteToken("}", '}')
+	}
+}
+
+// RoundTrip encodes v with enc into a buffer and decodes the result back
+// with dec. It helps verify that hand-written encode and decode functions,
+// such as EncodeJSON and DecodeJSON, are inverses of each other.
+func RoundTrip[T any](v T, enc func(e Encoder, v T) error, dec func(d Decoder) (T, error)) (T, error) {
+	var buf bytes.Buffer
+	if err := enc(NewEncoder(&buf), v); err != nil {
+		var zero T
+		return zero, err
+	}
+	return dec(NewDecoder(&buf))
+}
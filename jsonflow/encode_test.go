@@ -0,0 +1,469 @@
+/*
+ * Copyright 2025 The Go-Spring Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonflow
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	stdjson "encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lvan100/golib/testing/assert"
+)
+
+func TestEncodeAny(t *testing.T) {
+	t.Run("Round trip AnyList", func(t *testing.T) {
+		o := &TestObject{}
+		err := o.DecodeJSON(NewDecoder(strings.NewReader(`{"Int": 1, "AnyList": ["any1", 123, true, null, {"k": [1.5]}]}`)))
+		assert.That(t, err).Nil()
+
+		var buf bytes.Buffer
+		err = EncodeAny(NewEncoder(&buf), o.AnyList)
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal(`["any1",123,true,null,{"k":[1.5]}]` + "\n")
+
+		result, err := DecodeArray(DecodeAny)(NewDecoder(&buf))
+		assert.That(t, err).Nil()
+		assert.That(t, result).Equal(o.AnyList)
+	})
+
+	t.Run("Encode nil", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EncodeAny(NewEncoder(&buf), nil)
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal("null\n")
+	})
+
+	t.Run("Encode with encoder options", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf, ByteSliceAsArray(true), NilSliceAsNull(false))
+		err := EncodeAny(e, map[string]any{"b": []byte{1, 2}, "a": []int(nil)})
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal(`{"a":[],"b":[1,2]}` + "\n")
+	})
+
+	t.Run("Encode unsupported value", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EncodeAny(NewEncoder(&buf), make(chan int))
+		assert.Error(t, err).Matches("json: .* marshal from Go chan int")
+		assert.String(t, buf.String()).Equal("")
+	})
+}
+
+func TestEncodeMap(t *testing.T) {
+	m := map[int]string{1: "a", 2: "b", 10: "c", -3: "d"}
+
+	t.Run("Lexical key order by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EncodeMap(FormatIntKey[int], EncodeString)(NewEncoder(&buf), m)
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal(`{"-3":"d","1":"a","10":"c","2":"b"}` + "\n")
+	})
+
+	t.Run("Numeric key order", func(t *testing.T) {
+		numeric := MapKeyOrder(func(a, b string) int {
+			x, _ := strconv.Atoi(a)
+			y, _ := strconv.Atoi(b)
+			return cmp.Compare(x, y)
+		})
+		var buf bytes.Buffer
+		err := EncodeMap(FormatIntKey[int], EncodeString)(NewEncoder(&buf, numeric), m)
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal(`{"-3":"d","1":"a","2":"b","10":"c"}` + "\n")
+	})
+
+	t.Run("Key order ignored when not deterministic", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf, Deterministic(false), MapKeyOrder(strings.Compare))
+		assert.That(t, mapKeyOrder(e)).Nil()
+		err := EncodeMap(FormatIntKey[int], EncodeString)(e, m)
+		assert.That(t, err).Nil()
+		result, err := DecodeMap(DecodeIntKey[int], DecodeString)(NewDecoder(&buf))
+		assert.That(t, err).Nil()
+		assert.Map(t, result).Equal(m)
+	})
+
+	t.Run("Nested map with indent", func(t *testing.T) {
+		var buf bytes.Buffer
+		v := map[string]map[string]int{"b": {"y": 2, "x": 1}, "a": {}}
+		err := EncodeMap(FormatStringKey, EncodeMap(FormatStringKey, EncodeInt[int]))(NewEncoder(&buf, Indent("  ")), v)
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal("{\n  \"a\": {},\n  \"b\": {\n    \"x\": 1,\n    \"y\": 2\n  }\n}\n")
+	})
+
+	t.Run("Encoder without key order", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := struct{ Encoder }{NewEncoder(&buf)}
+		assert.That(t, mapKeyOrder(e)).Nil()
+		err := EncodeMap(FormatIntKey[int], EncodeString)(e, m)
+		assert.That(t, err).Nil()
+		result, err := DecodeMap(DecodeIntKey[int], DecodeString)(NewDecoder(&buf))
+		assert.That(t, err).Nil()
+		assert.Map(t, result).Equal(m)
+	})
+
+	t.Run("Encode nil map", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EncodeMap(FormatStringKey, EncodeInt[int])(NewEncoder(&buf), nil)
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal("null\n")
+	})
+}
+
+func (b *TestObject) EncodeJSON(e Encoder) error {
+	if err := EncodeObjectBegin(e); err != nil {
+		return err
+	}
+	fields := []struct {
+		key    string
+		encode func() error
+	}{
+		{"Int", func() error { return EncodeInt(e, b.Int) }},
+		{"IntPtr", func() error { return EncodeIntPtr(e, b.IntPtr) }},
+		{"Bytes", func() error { return EncodeBytes(e, b.Bytes) }},
+		{"Any", func() error { return EncodeAny(e, b.Any) }},
+		{"Object", func() error { return EncodeObject(e, b.Object) }},
+		{"StrList", func() error { return EncodeArray(EncodeString)(e, b.StrList) }},
+		{"StrPtrList", func() error { return EncodeArray(EncodeStringPtr)(e, b.StrPtrList) }},
+		{"ObjectList", func() error { return EncodeArray(EncodeObject[*TestObject])(e, b.ObjectList) }},
+		{"AnyList", func() error { return EncodeArray(EncodeAny)(e, b.AnyList) }},
+		{"IntIntList", func() error { return EncodeArray(EncodeArray(EncodeInt[int]))(e, b.IntIntList) }},
+		{"StrIntMapList", func() error {
+			return EncodeArray(EncodeMap(FormatStringKey, EncodeInt[int64]))(e, b.StrIntMapList)
+		}},
+		{"IntIntMap", func() error { return EncodeMap(FormatIntKey[int64], EncodeInt[int])(e, b.IntIntMap) }},
+		{"StrStrPtrMap", func() error { return EncodeMap(FormatStringKey, EncodeStringPtr)(e, b.StrStrPtrMap) }},
+		{"StrObjectMap", func() error {
+			return EncodeMap(FormatStringKey, EncodeObject[*TestObject])(e, b.StrObjectMap)
+		}},
+		{"StrIntMapIntMap", func() error {
+			return EncodeMap(FormatIntKey[int], EncodeMap(FormatStringKey, EncodeInt[int]))(e, b.StrIntMapIntMap)
+		}},
+		{"StrAnyListMap", func() error {
+			return EncodeMap(FormatStringKey, EncodeArray(EncodeAny))(e, b.StrAnyListMap)
+		}},
+	}
+	for _, f := range fields {
+		if err := EncodeKey(e, f.key); err != nil {
+			return err
+		}
+		if err := f.encode(); err != nil {
+			return err
+		}
+	}
+	return EncodeObjectEnd(e)
+}
+
+func TestMarshalObject(t *testing.T) {
+
+	t.Run("Full object", func(t *testing.T) {
+		i, str := 3, "str"
+		o := &TestObject{
+			Int:           1,
+			IntPtr:        &i,
+			Bytes:         []byte("hello"),
+			Any:           map[string]any{"k": []any{1.5, "v"}},
+			Object:        &TestObject{Int: 2, StrList: []string{}},
+			StrList:       []string{"a", "b"},
+			StrPtrList:    []*string{&str, nil},
+			ObjectList:    []*TestObject{{Int: 4}, nil},
+			AnyList:       []any{"x", 1.0, true, nil},
+			IntIntList:    [][]int{{1, 2}, nil, {}},
+			StrIntMapList: []map[string]int64{{"a": 1}, {}},
+			IntIntMap:     map[int64]int{-1: 1, 10: 2, 2: 3},
+			StrStrPtrMap:  map[string]*string{"a": &str, "b": nil},
+			StrObjectMap:  map[string]*TestObject{"o": {Int: 5}},
+			StrIntMapIntMap: map[int]map[string]int{
+				1: {"x": 1, "y": 2},
+				2: nil,
+			},
+			StrAnyListMap: map[string][]any{"l": {"a", 1.0, false}},
+		}
+
+		b, err := MarshalObject(o)
+		assert.That(t, err).Nil()
+		expect, err := stdjson.Marshal(o)
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).JSONEqual(string(expect))
+	})
+
+	t.Run("Empty object", func(t *testing.T) {
+		b, err := MarshalObject(&TestObject{})
+		assert.That(t, err).Nil()
+		expect, err := stdjson.Marshal(&TestObject{})
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).JSONEqual(string(expect))
+	})
+
+	t.Run("Nil object", func(t *testing.T) {
+		b, err := MarshalObject((*TestObject)(nil))
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal("null")
+	})
+
+	t.Run("Nil slices and maps as empty", func(t *testing.T) {
+		o := &TestObject{Int: 1, AnyList: []any{[]int(nil)}, IntIntList: [][]int{nil}, StrIntMapList: []map[string]int64{nil}}
+		opts := []MarshalOptions{NilSliceAsNull(false), NilMapAsNull(false)}
+		b, err := MarshalObject(o, opts...)
+		assert.That(t, err).Nil()
+		expect, err := Marshal(o, opts...)
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal(string(expect))
+	})
+
+	t.Run("Indent", func(t *testing.T) {
+		o := &TestObject{Int: 1, StrList: []string{"a"}}
+		b, err := MarshalObject(o, Indent("  "))
+		assert.That(t, err).Nil()
+		expect, err := Marshal(o, Indent("  "))
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal(string(expect))
+	})
+}
+
+func TestEncodeArrayFunc(t *testing.T) {
+
+	t.Run("Generated sequence", func(t *testing.T) {
+		seq := func(yield func(int) bool) {
+			for i := range 1000 {
+				if !yield(i * i) {
+					return
+				}
+			}
+		}
+		var buf bytes.Buffer
+		err := EncodeArrayFunc(NewEncoder(&buf), iter.Seq[int](seq), EncodeInt[int])
+		assert.That(t, err).Nil()
+
+		result, err := DecodeArray(DecodeInt[int])(NewDecoder(&buf))
+		assert.That(t, err).Nil()
+		assert.Number(t, len(result)).Equal(1000)
+		for i, v := range result {
+			assert.Number(t, v).Equal(i * i)
+		}
+	})
+
+	t.Run("Empty sequence", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EncodeArrayFunc(NewEncoder(&buf), func(func(string) bool) {}, EncodeString)
+		assert.That(t, err).Nil()
+		assert.String(t, buf.String()).Equal("[]\n")
+	})
+
+	t.Run("Element error stops iteration", func(t *testing.T) {
+		errStop := errors.New("stop")
+		visited := 0
+		seq := func(yield func(int) bool) {
+			for i := range 10 {
+				visited++
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		var buf bytes.Buffer
+		err := EncodeArrayFunc(NewEncoder(&buf), seq, func(e Encoder, v int) error {
+			if v == 2 {
+				return errStop
+			}
+			return EncodeInt(e, v)
+		})
+		assert.Error(t, err).Is(errStop)
+		assert.Number(t, visited).Equal(3)
+	})
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	t.Run("Scalars", func(t *testing.T) {
+		i, err := RoundTrip(-42, EncodeInt[int], DecodeInt[int])
+		assert.That(t, err).Nil()
+		assert.Number(t, i).Equal(-42)
+
+		s, err := RoundTrip("a \"quoted\" \u00e9 string\n", EncodeString, DecodeString)
+		assert.That(t, err).Nil()
+		assert.String(t, s).Equal("a \"quoted\" \u00e9 string\n")
+
+		b, err := RoundTrip([]byte{0, 1, 2, 255}, EncodeBytes, DecodeBytes)
+		assert.That(t, err).Nil()
+		assert.That(t, b).Equal([]byte{0, 1, 2, 255})
+	})
+
+	t.Run("Arrays", func(t *testing.T) {
+		v := [][]int{{1, 2}, {}, nil, {3}}
+		r, err := RoundTrip(v, EncodeArray(EncodeArray(EncodeInt[int])), DecodeArray(DecodeArray(DecodeInt[int])))
+		assert.That(t, err).Nil()
+		assert.That(t, r).Equal(v)
+	})
+
+	t.Run("Maps", func(t *testing.T) {
+		v := map[int64]map[string]int{1: {"a": 1}, -2: {}, 3: nil}
+		r, err := RoundTrip(v,
+			EncodeMap(FormatIntKey[int64], EncodeMap(FormatStringKey, EncodeInt[int])),
+			DecodeMap(DecodeIntKey[int64], DecodeMap(DecodeString, DecodeInt[int])),
+		)
+		assert.That(t, err).Nil()
+		assert.That(t, r).Equal(v)
+	})
+
+	t.Run("Full TestObject", func(t *testing.T) {
+		i, str := 3, "str"
+		v := &TestObject{
+			Int:           1,
+			IntPtr:        &i,
+			Bytes:         []byte("hello"),
+			Any:           map[string]any{"k": []any{1.5, "v", nil}},
+			Object:        &TestObject{Int: 2, Bytes: []byte{}, StrList: []string{}},
+			StrList:       []string{"a", "b"},
+			StrPtrList:    []*string{&str, nil},
+			ObjectList:    []*TestObject{{Int: 4, Bytes: []byte("x")}, nil},
+			AnyList:       []any{"x", 1.0, true, nil},
+			IntIntList:    [][]int{{1, 2}, nil, {}},
+			StrIntMapList: []map[string]int64{{"a": 1}, {}},
+			IntIntMap:     map[int64]int{-1: 1, 10: 2, 2: 3},
+			StrStrPtrMap:  map[string]*string{"a": &str, "b": nil},
+			StrObjectMap:  map[string]*TestObject{"o": {Int: 5, Bytes: []byte("y")}},
+			StrIntMapIntMap: map[int]map[string]int{
+				1: {"x": 1, "y": 2},
+				2: nil,
+			},
+			StrAnyListMap: map[string][]any{"l": {"a", 1.0, false}},
+		}
+		r, err := RoundTrip(v, EncodeObject[*TestObject], DecodeObject(NewTestObject))
+		assert.That(t, err).Nil()
+		assert.That(t, r).Equal(v)
+	})
+
+	t.Run("Decode error", func(t *testing.T) {
+		_, err := RoundTrip(300, EncodeInt[int], func(d Decoder) (int, error) {
+			v, err := DecodeInt[int8](d)
+			return int(v), err
+		})
+		assert.Error(t, err).Contains("number out of range")
+	})
+}
+
+func TestByteSliceAsArray(t *testing.T) {
+	type Payload struct {
+		Data  []byte
+		Empty []byte
+		Nil   []byte
+		Raw   RawMessage
+	}
+	v := Payload{Data: []byte{1, 2, 3}, Empty: []byte{}, Raw: RawMessage(`{"a":1}`)}
+
+	t.Run("Default base64", func(t *testing.T) {
+		b, err := Marshal(v)
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal(`{"Data":"AQID","Empty":"","Nil":null,"Raw":{"a":1}}`)
+	})
+
+	t.Run("Array of numbers", func(t *testing.T) {
+		b, err := Marshal(v, ByteSliceAsArray(true))
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal(`{"Data":[1,2,3],"Empty":[],"Nil":null,"Raw":{"a":1}}`)
+	})
+
+	t.Run("Array with nil slice as empty", func(t *testing.T) {
+		b, err := Marshal(v, ByteSliceAsArray(true), NilSliceAsNull(false))
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal(`{"Data":[1,2,3],"Empty":[],"Nil":[],"Raw":{"a":1}}`)
+	})
+
+	t.Run("Explicitly disabled", func(t *testing.T) {
+		b, err := Marshal([]byte{255}, ByteSliceAsArray(false))
+		assert.That(t, err).Nil()
+		assert.String(t, string(b)).Equal(`"/w=="`)
+	})
+
+	t.Run("EncodeBytes", func(t *testing.T) {
+		encode := func(b []byte, opts ...MarshalOptions) string {
+			var buf bytes.Buffer
+			err := EncodeArray(EncodeBytes)(NewEncoder(&buf, opts...), [][]byte{b, nil})
+			assert.That(t, err).Nil()
+			return strings.TrimSpace(buf.String())
+		}
+		assert.String(t, encode([]byte{1, 2, 3})).Equal(`["AQID",null]`)
+		assert.String(t, encode([]byte{1, 2, 3}, ByteSliceAsArray(true))).Equal(`[[1,2,3],null]`)
+		assert.String(t, encode([]byte{}, ByteSliceAsArray(true), NilSliceAsNull(false))).Equal(`[[],[]]`)
+		assert.String(t, encode([]byte{255}, NilSliceAsNull(false))).Equal(`["/w==",""]`)
+	})
+}
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (w *flushRecorder) Flush() {
+	w.flushes++
+}
+
+func TestEncoderFlush(t *testing.T) {
+
+	t.Run("Flush buffered writer incrementally", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		bw := bufio.NewWriter(pw)
+		e := NewEncoder(bw)
+		r := bufio.NewReader(pr)
+
+		for i := range 3 {
+			errCh := make(chan error, 1)
+			go func() {
+				if err := EncodeMap(FormatStringKey, EncodeInt[int])(e, map[string]int{"seq": i}); err != nil {
+					errCh <- err
+					return
+				}
+				errCh <- Flush(e)
+			}()
+			line, err := r.ReadString('\n')
+			assert.That(t, err).Nil()
+			assert.String(t, line).Equal(`{"seq":` + strconv.Itoa(i) + "}\n")
+			assert.That(t, <-errCh).Nil()
+		}
+	})
+
+	t.Run("Flush http.Flusher-style writer", func(t *testing.T) {
+		var w flushRecorder
+		e := NewEncoder(&w)
+		assert.That(t, EncodeString(e, "a")).Nil()
+		assert.That(t, Flush(e)).Nil()
+		assert.That(t, w.flushes).Equal(1)
+		assert.String(t, w.String()).Equal("\"a\"\n")
+	})
+
+	t.Run("Flush encoder without Flush", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := struct{ Encoder }{NewEncoder(&buf)}
+		assert.That(t, EncodeInt(e, 1)).Nil()
+		assert.That(t, Flush(e)).Nil()
+	})
+
+	t.Run("Flush plain writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		assert.That(t, EncodeInt(e, 1)).Nil()
+		assert.That(t, Flush(e)).Nil()
+		assert.String(t, buf.String()).Equal("1\n")
+	})
+}
@@ -21,6 +21,10 @@ package json
 // Only the lowest level interface is preserved,
 // higher level requires some shallow encapsulation.
 type Encoder interface {
+	// WriteToken writes the next token of the given kind. The token holds
+	// the unquoted content for strings and the literal text for numbers,
+	// and is ignored for null, booleans, and object or array delimiters.
+	WriteToken(token string, _ Kind) error
 	// WriteValue writes a JSON value to the encoder.
 	WriteValue(v []byte) error
 }
@@ -26,6 +26,13 @@ import (
 // It provides streaming JSON decoding with convenience methods for reading tokens and values.
 type Decoder struct {
 	*jsontext.Decoder
+
+	// MaxDepth limits the nesting depth reported by StackDepth; 0 means no limit.
+	MaxDepth int
+
+	// BaseDepth is added to StackDepth, for decoders over values that
+	// were buffered from another decoder while it was already nested.
+	BaseDepth int
 }
 
 // toKind converts jsontext.Kind to the json.Kind.
@@ -81,3 +88,14 @@ func (d *Decoder) ReadValue() ([]byte, error) {
 func (d *Decoder) SkipValue() error {
 	return d.Decoder.SkipValue()
 }
+
+// StackDepth returns the nesting depth of the objects and arrays
+// that have been entered but not yet exited, plus BaseDepth.
+func (d *Decoder) StackDepth() int {
+	return d.BaseDepth + d.Decoder.StackDepth()
+}
+
+// MaxStackDepth returns the nesting depth limit of the decoder.
+func (d *Decoder) MaxStackDepth() int {
+	return d.MaxDepth
+}
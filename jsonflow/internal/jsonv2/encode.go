@@ -18,14 +18,105 @@ package jsonv2
 
 import (
 	"encoding/json/jsontext"
+	stdjsonv2 "encoding/json/v2"
+	"fmt"
+	"io"
+
+	"github.com/lvan100/golib/jsonflow/internal/json"
 )
 
 // Encoder wraps jsontext.Encoder to implement the json.Encoder interface.
 type Encoder struct {
 	*jsontext.Encoder
+
+	// KeyOrder orders the encoded keys of maps; nil leaves them unordered.
+	KeyOrder func(a, b string) int
+
+	// Writer is the underlying writer, flushed by Flush.
+	Writer io.Writer
+
+	// NilSliceAsNull and NilMapAsNull report whether nil slices and maps
+	// are encoded as null rather than as empty arrays and objects.
+	NilSliceAsNull bool
+	NilMapAsNull   bool
+
+	// ByteSliceAsArray reports whether byte slices are encoded as arrays
+	// of numbers rather than as base64 strings.
+	ByteSliceAsArray bool
+}
+
+// WriteToken writes the next JSON token of the given kind to the encoder.
+func (e *Encoder) WriteToken(token string, k json.Kind) error {
+	switch k {
+	case 'n':
+		return e.Encoder.WriteToken(jsontext.Null)
+	case 'f':
+		return e.Encoder.WriteToken(jsontext.False)
+	case 't':
+		return e.Encoder.WriteToken(jsontext.True)
+	case '"':
+		return e.Encoder.WriteToken(jsontext.String(token))
+	case '0':
+		return e.Encoder.WriteValue(jsontext.Value(token))
+	case '{':
+		return e.Encoder.WriteToken(jsontext.BeginObject)
+	case '}':
+		return e.Encoder.WriteToken(jsontext.EndObject)
+	case '[':
+		return e.Encoder.WriteToken(jsontext.BeginArray)
+	case ']':
+		return e.Encoder.WriteToken(jsontext.EndArray)
+	default:
+		return fmt.Errorf("invalid JSON token kind %q", byte(k))
+	}
 }
 
 // WriteValue writes a JSON value to the encoder.
 func (e *Encoder) WriteValue(v []byte) error {
 	return e.Encoder.WriteValue(v)
 }
+
+// MarshalValue marshals v with the options the encoder was created with
+// and writes the result as the next value. Nothing is written if
+// marshaling fails.
+func (e *Encoder) MarshalValue(v any) error {
+	b, err := stdjsonv2.Marshal(v, e.Encoder.Options())
+	if err != nil {
+		return err
+	}
+	return e.Encoder.WriteValue(b)
+}
+
+// MapKeyOrder returns the comparison function used to order map keys.
+func (e *Encoder) MapKeyOrder() func(a, b string) int {
+	return e.KeyOrder
+}
+
+// FormatNilSliceAsNull reports whether nil slices are encoded as null.
+func (e *Encoder) FormatNilSliceAsNull() bool {
+	return e.NilSliceAsNull
+}
+
+// FormatNilMapAsNull reports whether nil maps are encoded as null.
+func (e *Encoder) FormatNilMapAsNull() bool {
+	return e.NilMapAsNull
+}
+
+// FormatByteSliceAsArray reports whether byte slices are encoded as arrays.
+func (e *Encoder) FormatByteSliceAsArray() bool {
+	return e.ByteSliceAsArray
+}
+
+// Flush flushes the underlying writer if it implements Flush() error,
+// as bufio.Writer does, or Flush(), as http.Flusher does.
+func (e *Encoder) Flush() error {
+	switch w := e.Writer.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Flush() }:
+		w.Flush()
+		return nil
+	default:
+		return nil
+	}
+}
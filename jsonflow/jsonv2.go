@@ -17,22 +17,96 @@
 package jsonflow
 
 import (
+	"bytes"
+	stdjson "encoding/json"
 	"encoding/json/jsontext"
 	stdjsonv2 "encoding/json/v2"
 	"io"
+	"strings"
 
 	"github.com/lvan100/golib/jsonflow/internal/json"
 	"github.com/lvan100/golib/jsonflow/internal/jsonv2"
 )
 
+// RawMessage is a raw encoded JSON value.
+type RawMessage = stdjson.RawMessage
+
 // NewEncoder creates a new jsonv2.Encoder that implements the json.Encoder interface.
-func NewEncoder(w io.Writer) json.Encoder {
-	return &jsonv2.Encoder{Encoder: jsontext.NewEncoder(w)}
+// The options control indentation, how EncodeArray, EncodeMap and EncodeBytes
+// write nil values, whether EncodeBytes writes an array of numbers, and, when Deterministic is enabled (the default), the order
+// of map keys written by EncodeMap.
+func NewEncoder(w io.Writer, opts ...MarshalOptions) json.Encoder {
+	e := &jsonv2.Encoder{
+		Encoder:        jsontext.NewEncoder(w, toJSONv2Options(opts)...),
+		KeyOrder:       toMapKeyOrder(opts),
+		Writer:         w,
+		NilSliceAsNull: true,
+		NilMapAsNull:   true,
+	}
+	for _, opt := range opts {
+		switch x := opt.(type) {
+		case NilSliceAsNull:
+			e.NilSliceAsNull = bool(x)
+		case NilMapAsNull:
+			e.NilMapAsNull = bool(x)
+		case ByteSliceAsArray:
+			e.ByteSliceAsArray = bool(x)
+		default: // for linter
+		}
+	}
+	return e
+}
+
+// toMapKeyOrder returns the map key comparison function selected by the options.
+// Keys are compared lexically unless MapKeyOrder is given, and are left
+// unordered when Deterministic is disabled.
+func toMapKeyOrder(opts []MarshalOptions) func(a, b string) int {
+	deterministic := true
+	keyOrder := strings.Compare
+	for _, opt := range opts {
+		switch x := opt.(type) {
+		case Deterministic:
+			deterministic = bool(x)
+		case MapKeyOrder:
+			keyOrder = x
+		default: // for linter
+		}
+	}
+	if !deterministic {
+		return nil
+	}
+	return keyOrder
 }
 
 // NewDecoder creates a new jsonv2.Decoder that implements the json.Decoder interface.
-func NewDecoder(r io.Reader) json.Decoder {
-	return &jsonv2.Decoder{Decoder: jsontext.NewDecoder(r)}
+// The MaxDepth option limits how deeply objects and arrays may nest.
+func NewDecoder(r io.Reader, opts ...UnmarshalOptions) json.Decoder {
+	d := &jsonv2.Decoder{Decoder: jsontext.NewDecoder(r), MaxDepth: defaultMaxDepth}
+	for _, opt := range opts {
+		switch x := opt.(type) {
+		case MaxDepth:
+			d.MaxDepth = max(int(x), 0)
+		default: // for linter
+		}
+	}
+	return d
+}
+
+// newSubDecoder creates a decoder over b, a value just read from d, whose
+// depth accounting continues from d's current depth and limit, so that
+// re-decoding buffered values cannot bypass the MaxDepth guard.
+func newSubDecoder(d json.Decoder, b []byte) json.Decoder {
+	sub := &jsonv2.Decoder{Decoder: jsontext.NewDecoder(bytes.NewReader(b)), MaxDepth: defaultMaxDepth}
+	if dd, ok := d.(depthDecoder); ok {
+		sub.MaxDepth = dd.MaxStackDepth()
+		sub.BaseDepth = dd.StackDepth()
+	}
+	return sub
+}
+
+// appendUnquote appends the unescaped content of the quoted JSON string src to dst.
+func appendUnquote(dst, src []byte) ([]byte, error) {
+	return jsontext.AppendUnquote(dst, src)
 }
 
 // toJSONv2Options converts MarshalOptions to jsontext.Options.
@@ -58,12 +132,35 @@ func toJSONv2Options(opts []MarshalOptions) []jsontext.Options {
 			ret = append(ret, stdjsonv2.FormatNilMapAsNull(bool(x)))
 		case Deterministic:
 			ret = append(ret, stdjsonv2.Deterministic(bool(x)))
+		case ByteSliceAsArray:
+			if x {
+				ret = append(ret, stdjsonv2.WithMarshalers(byteSliceAsArray))
+			}
 		default: // for linter
 		}
 	}
 	return ret
 }
 
+// byteSliceAsArray marshals []byte as a JSON array of numbers,
+// honoring the FormatNilSliceAsNull option for nil slices.
+var byteSliceAsArray = stdjsonv2.MarshalToFunc(func(enc *jsontext.Encoder, b []byte) error {
+	if b == nil {
+		if asNull, _ := stdjsonv2.GetOption(enc.Options(), stdjsonv2.FormatNilSliceAsNull); asNull {
+			return enc.WriteToken(jsontext.Null)
+		}
+	}
+	if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+		return err
+	}
+	for _, c := range b {
+		if err := enc.WriteToken(jsontext.Uint(uint64(c))); err != nil {
+			return err
+		}
+	}
+	return enc.WriteToken(jsontext.EndArray)
+})
+
 // Marshal marshals a Go value into JSON bytes.
 func Marshal(i any, opts ...MarshalOptions) ([]byte, error) {
 	return stdjsonv2.Marshal(i, toJSONv2Options(opts)...)
@@ -88,3 +185,11 @@ func Unmarshal(b []byte, i any) error {
 func UnmarshalRead(r io.Reader, i any) error {
 	return stdjsonv2.UnmarshalRead(r, i)
 }
+
+// unmarshalUseNumber unmarshals JSON bytes into a Go value,
+// decoding numbers held in interface values as json.Number.
+func unmarshalUseNumber(b []byte, i any) error {
+	d := stdjson.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(i)
+}
@@ -19,7 +19,9 @@ package internal
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"strings"
 )
 
 // ErrorAssertion provides assertion methods for values of type error.
@@ -40,10 +42,13 @@ func ThatError(t TestingT, v error, fatalOnFailure bool) *ErrorAssertion {
 	}
 }
 
-// Nil reports a test failure if the error is not nil.
+// Nil reports a test failure if the error is not nil. A typed nil, such as a
+// nil *MyError held in the error interface, is considered nil, consistent with
+// Assertion.Nil. Note that such an error still compares != nil in Go, so Nil
+// does not catch a function that wrongly returns a typed nil error.
 func (a *ErrorAssertion) Nil(msg ...string) *ErrorAssertion {
 	a.t.Helper()
-	if a.v != nil {
+	if !isNil(reflect.ValueOf(a.v)) {
 		str := fmt.Sprintf(`expected error to be nil, but it is not
   actual: (%T) %q`, a.v, a.v.Error())
 		Fail(a.t, a.fatalOnFailure, str, msg...)
@@ -51,10 +56,10 @@ func (a *ErrorAssertion) Nil(msg ...string) *ErrorAssertion {
 	return a
 }
 
-// NotNil reports a test failure if the error is nil.
+// NotNil reports a test failure if the error is nil, including a typed nil.
 func (a *ErrorAssertion) NotNil(msg ...string) *ErrorAssertion {
 	a.t.Helper()
-	if a.v == nil {
+	if isNil(reflect.ValueOf(a.v)) {
 		str := `expected error to be non-nil, but it is nil`
 		Fail(a.t, a.fatalOnFailure, str, msg...)
 	}
@@ -88,7 +93,7 @@ expected: %v`, a.v, target)
 // String reports a test failure if the error message is not equal to the expected message.
 func (a *ErrorAssertion) String(expect string, msg ...string) *ErrorAssertion {
 	a.t.Helper()
-	if a.v == nil {
+	if isNil(reflect.ValueOf(a.v)) {
 		str := `expected non-nil error, but got nil`
 		Fail(a.t, a.fatalOnFailure, str, msg...)
 		return a
@@ -107,7 +112,7 @@ expected: %q`, a.v, expect)
 // to validate the error message content. Optional custom failure messages can be provided.
 func (a *ErrorAssertion) Matches(expr string, msg ...string) *ErrorAssertion {
 	a.t.Helper()
-	if a.v == nil {
+	if isNil(reflect.ValueOf(a.v)) {
 		str := `expected non-nil error, but got nil`
 		Fail(a.t, a.fatalOnFailure, str, msg...)
 		return a
@@ -121,3 +126,22 @@ func (a *ErrorAssertion) Matches(expr string, msg ...string) *ErrorAssertion {
 	}
 	return a
 }
+
+// Contains reports a test failure if the error message does not contain the
+// given substring. It expects a non-nil error and reports the full message on
+// failure, which suits messages carrying volatile details such as offsets.
+func (a *ErrorAssertion) Contains(substr string, msg ...string) *ErrorAssertion {
+	a.t.Helper()
+	if isNil(reflect.ValueOf(a.v)) {
+		str := `expected non-nil error, but got nil`
+		Fail(a.t, a.fatalOnFailure, str, msg...)
+		return a
+	}
+	if s := a.v.Error(); !strings.Contains(s, substr) {
+		str := fmt.Sprintf(`expected error message to contain the specified substring, but it does not
+  actual: %q
+     sub: %q`, s, substr)
+		Fail(a.t, a.fatalOnFailure, str, msg...)
+	}
+	return a
+}
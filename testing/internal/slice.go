@@ -17,7 +17,9 @@
 package internal
 
 import (
+	"cmp"
 	"fmt"
+	"reflect"
 	"slices"
 )
 
@@ -309,3 +311,55 @@ func (a *SliceAssertion[T]) NoneMatches(fn func(T) bool, msg ...string) *SliceAs
 	}
 	return a
 }
+
+// Sorted asserts that the slice is sorted in ascending order.
+// The element type must be an integer, float or string type.
+func (a *SliceAssertion[T]) Sorted(msg ...string) *SliceAssertion[T] {
+	a.t.Helper()
+	return a.sorted("ascending", 1, msg...)
+}
+
+// SortedDesc asserts that the slice is sorted in descending order.
+// The element type must be an integer, float or string type.
+func (a *SliceAssertion[T]) SortedDesc(msg ...string) *SliceAssertion[T] {
+	a.t.Helper()
+	return a.sorted("descending", -1, msg...)
+}
+
+// sorted asserts that every pair of adjacent elements compares in the given
+// direction (1 for ascending, -1 for descending) or equal.
+func (a *SliceAssertion[T]) sorted(order string, dir int, msg ...string) *SliceAssertion[T] {
+	a.t.Helper()
+	for i := 1; i < len(a.v); i++ {
+		c, ok := compareOrdered(reflect.ValueOf(a.v[i-1]), reflect.ValueOf(a.v[i]))
+		if !ok {
+			str := fmt.Sprintf(`expected slice of an ordered type, but element type is %T`, a.v[i])
+			Fail(a.t, a.fatalOnFailure, str, msg...)
+			return a
+		}
+		if c*dir > 0 {
+			str := fmt.Sprintf(`expected slice to be sorted in %s order, but element at index %d is out of order
+  actual: %v`, order, i, ToJSONString(a.v))
+			Fail(a.t, a.fatalOnFailure, str, msg...)
+			return a
+		}
+	}
+	return a
+}
+
+// compareOrdered compares two values of the same integer, float or string kind.
+// It reports false if the kind is not ordered.
+func compareOrdered(x, y reflect.Value) (int, bool) {
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(x.Int(), y.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(x.Uint(), y.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(x.Float(), y.Float()), true
+	case reflect.String:
+		return cmp.Compare(x.String(), y.String()), true
+	default:
+		return 0, false
+	}
+}
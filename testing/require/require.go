@@ -37,6 +37,18 @@ func That(t internal.TestingT, v any) *internal.Assertion {
 	return internal.That(t, v, fatalOnFailure)
 }
 
+// NoError asserts that `err` is nil.
+// It reports the error's type and message if `err` is not nil.
+//
+// Note: like That(...).Nil, NoError treats a typed nil, such as a nil *MyError
+// held in the error interface, as nil, although `err != nil` holds for it in Go.
+// To catch a function that wrongly returns a typed nil error, assert on the
+// interface itself instead, e.g. That(t, err == nil).True().
+func NoError(t internal.TestingT, err error, msg ...string) {
+	t.Helper()
+	internal.ThatError(t, err, fatalOnFailure).Nil(msg...)
+}
+
 // Error returns a new ErrorAssertion for the given error value.
 func Error(t internal.TestingT, v error) *internal.ErrorAssertion {
 	return internal.ThatError(t, v, fatalOnFailure)
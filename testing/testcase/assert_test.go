@@ -314,6 +314,20 @@ func TestThat_Nil(t *testing.T) {
 	var fn func()
 	assert.That(m, fn).Nil()
 	assert.String(t, m.String()).Equal("")
+
+	// Test with typed nil pointer held in an interface
+	m.Reset()
+	var typedNil *CustomError
+	var err error = typedNil
+	assert.That(m, err).Nil()
+	assert.String(t, m.String()).Equal("")
+
+	// Test with non-nil value held in an interface
+	m.Reset()
+	err = &CustomError{msg: "custom error"}
+	assert.That(m, err).Nil()
+	assert.String(t, m.String()).Matches(`error# Assertion failed: expected value to be nil, but it is not
+  actual: \(\*testcase_test.CustomError\) .*custom error`)
 }
 
 func TestThat_NotNil(t *testing.T) {
@@ -375,6 +389,13 @@ func TestThat_NotNil(t *testing.T) {
 	fn = func() {}
 	assert.That(m, fn).NotNil()
 	assert.String(t, m.String()).Equal("")
+
+	// Test with typed nil pointer held in an interface
+	m.Reset()
+	var typedNil *CustomError
+	var err error = typedNil
+	assert.That(m, err).NotNil()
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected value to be non-nil, but it is nil`)
 }
 
 func TestThat_Equal(t *testing.T) {
@@ -55,6 +55,19 @@ func TestError_Nil(t *testing.T) {
   actual: (*errors.errorString) "this is an error"
  message: "index is 0"`)
 
+	// Test with typed nil pointer held in the error interface - should pass
+	m.Reset()
+	var typedNil *CustomError
+	assert.Error(m, typedNil).Nil()
+	assert.NoError(m, typedNil)
+	assert.String(t, m.String()).Equal("")
+
+	// Test with non-nil custom error - should fail
+	m.Reset()
+	assert.Error(m, &CustomError{msg: "custom error"}).Nil()
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected error to be nil, but it is not
+  actual: (*testcase_test.CustomError) "custom error"`)
+
 	// Test with custom message
 	m.Reset()
 	assert.Error(m, errors.New("test error")).Nil("expected no error in this operation")
@@ -63,6 +76,34 @@ func TestError_Nil(t *testing.T) {
  message: "expected no error in this operation"`)
 }
 
+func TestNoError(t *testing.T) {
+	m := new(internal.MockTestingT)
+
+	// Test with nil error - should pass
+	m.Reset()
+	assert.NoError(m, nil)
+	assert.String(t, m.String()).Equal("")
+
+	// Test with non-nil error - should fail
+	m.Reset()
+	assert.NoError(m, errors.New("this is an error"))
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected error to be nil, but it is not
+  actual: (*errors.errorString) "this is an error"`)
+
+	// Test with Require mode - should fatal
+	m.Reset()
+	require.NoError(m, errors.New("this is an error"), "index is 0")
+	assert.String(t, m.String()).Equal(`fatal# Assertion failed: expected error to be nil, but it is not
+  actual: (*errors.errorString) "this is an error"
+ message: "index is 0"`)
+
+	// Test with wrapped custom error
+	m.Reset()
+	assert.NoError(m, fmt.Errorf("open config: %w", &CustomError{msg: "file not found"}))
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected error to be nil, but it is not
+  actual: (*fmt.wrapError) "open config: file not found"`)
+}
+
 func TestError_NotNil(t *testing.T) {
 	m := new(internal.MockTestingT)
 
@@ -82,6 +123,12 @@ func TestError_NotNil(t *testing.T) {
 	assert.String(t, m.String()).Equal(`fatal# Assertion failed: expected error to be non-nil, but it is nil
  message: "index is 0"`)
 
+	// Test with typed nil pointer held in the error interface - should fail
+	m.Reset()
+	var typedNil *CustomError
+	assert.Error(m, typedNil).NotNil()
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected error to be non-nil, but it is nil`)
+
 	// Test with custom message
 	m.Reset()
 	assert.Error(m, nil).NotNil("expected an error in this operation")
@@ -229,6 +276,12 @@ expected: "another error"
 	assert.Error(m, nil).String(err.Error())
 	assert.String(t, m.String()).Equal(`error# Assertion failed: expected non-nil error, but got nil`)
 
+	// Test with typed nil pointer held in the error interface - should fail, not panic
+	m.Reset()
+	var typedNil *CustomError
+	assert.Error(m, typedNil).String(err.Error())
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected non-nil error, but got nil`)
+
 	// Test with custom error type
 	m.Reset()
 	customErr := &CustomError{msg: "custom error"}
@@ -262,6 +315,12 @@ func TestError_Matches(t *testing.T) {
 	assert.Error(m, nil).Matches("an error")
 	assert.String(t, m.String()).Equal("error# Assertion failed: expected non-nil error, but got nil")
 
+	// Test with typed nil pointer held in the error interface - should fail, not panic
+	m.Reset()
+	var typedNil *CustomError
+	assert.Error(m, typedNil).Matches("an error")
+	assert.String(t, m.String()).Equal("error# Assertion failed: expected non-nil error, but got nil")
+
 	// Test with nil error and custom message
 	m.Reset()
 	assert.Error(m, nil).Matches("an error", "index is 0")
@@ -305,3 +364,43 @@ func TestError_Matches(t *testing.T) {
 	assert.String(t, m.String()).Equal(`error# Assertion failed: got "some error" which does not match "nonexistent"
  message: "expected error to match pattern"`)
 }
+
+func TestError_Contains(t *testing.T) {
+	m := new(internal.MockTestingT)
+
+	// Test successful case - substring is contained
+	m.Reset()
+	assert.Error(m, errors.New("jsontext: invalid character at offset 12")).Contains("invalid character")
+	assert.String(t, m.String()).Equal("")
+
+	// Test failed case - substring is missing
+	m.Reset()
+	assert.Error(m, errors.New("this is an error")).Contains("timeout")
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected error message to contain the specified substring, but it does not
+  actual: "this is an error"
+     sub: "timeout"`)
+
+	// Test with nil error - should fail
+	m.Reset()
+	assert.Error(m, nil).Contains("an error")
+	assert.String(t, m.String()).Equal("error# Assertion failed: expected non-nil error, but got nil")
+
+	// Test with typed nil pointer held in the error interface - should fail, not panic
+	m.Reset()
+	var typedNil *CustomError
+	assert.Error(m, typedNil).Contains("an error")
+	assert.String(t, m.String()).Equal("error# Assertion failed: expected non-nil error, but got nil")
+
+	// Test with wrapped error - full message is searched
+	m.Reset()
+	assert.Error(m, fmt.Errorf("level 1: %w", errors.New("root error"))).Contains("root")
+	assert.String(t, m.String()).Equal("")
+
+	// Test failed case with Require and custom message - should fatal
+	m.Reset()
+	require.Error(m, errors.New("this is an error")).Contains("timeout", "index is 0")
+	assert.String(t, m.String()).Equal(`fatal# Assertion failed: expected error message to contain the specified substring, but it does not
+  actual: "this is an error"
+     sub: "timeout"
+ message: "index is 0"`)
+}
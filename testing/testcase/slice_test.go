@@ -1044,3 +1044,64 @@ func TestSlice_NoneMatches(t *testing.T) {
 	assert.Slice(m, []struct{ A, B int }{{1, 3}, {3, 5}}).NoneMatches(func(s struct{ A, B int }) bool { return s.A%2 == 0 })
 	assert.String(t, m.String()).Equal("")
 }
+
+func TestSlice_Sorted(t *testing.T) {
+	m := new(internal.MockTestingT)
+
+	// Test successful sorted check
+	m.Reset()
+	assert.Slice(m, []int{1, 2, 2, 3}).Sorted()
+	assert.String(t, m.String()).Equal("")
+
+	// Test empty and single element slices
+	m.Reset()
+	assert.Slice(m, []int(nil)).Sorted()
+	assert.Slice(m, []string{"a"}).Sorted()
+	assert.String(t, m.String()).Equal("")
+
+	// Test sorted strings and floats
+	m.Reset()
+	assert.Slice(m, []string{"a", "ab", "b"}).Sorted()
+	assert.Slice(m, []float64{-1.5, 0, 2.5}).Sorted()
+	assert.String(t, m.String()).Equal("")
+
+	// Test reverse-sorted slice
+	m.Reset()
+	assert.Slice(m, []int{3, 2, 1}).Sorted()
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected slice to be sorted in ascending order, but element at index 1 is out of order
+  actual: [3,2,1]`)
+
+	// Test unsorted slice with Require mode
+	m.Reset()
+	require.Slice(m, []uint{1, 3, 2, 4}).Sorted("index is 0")
+	assert.String(t, m.String()).Equal(`fatal# Assertion failed: expected slice to be sorted in ascending order, but element at index 2 is out of order
+  actual: [1,3,2,4]
+ message: "index is 0"`)
+
+	// Test unordered element type
+	m.Reset()
+	assert.Slice(m, []bool{true, false}).Sorted()
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected slice of an ordered type, but element type is bool`)
+}
+
+func TestSlice_SortedDesc(t *testing.T) {
+	m := new(internal.MockTestingT)
+
+	// Test successful descending check
+	m.Reset()
+	assert.Slice(m, []int{3, 2, 2, 1}).SortedDesc()
+	assert.String(t, m.String()).Equal("")
+
+	// Test ascending slice
+	m.Reset()
+	assert.Slice(m, []int{1, 2, 3}).SortedDesc()
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected slice to be sorted in descending order, but element at index 1 is out of order
+  actual: [1,2,3]`)
+
+	// Test unsorted slice with custom message
+	m.Reset()
+	assert.Slice(m, []string{"c", "b", "d"}).SortedDesc("keys should be descending")
+	assert.String(t, m.String()).Equal(`error# Assertion failed: expected slice to be sorted in descending order, but element at index 2 is out of order
+  actual: ["c","b","d"]
+ message: "keys should be descending"`)
+}